@@ -3,12 +3,14 @@ package main
 //nolint:depguard
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	Logger     LoggerConf
+	Calendar   CalendarConf
 	SQL        SQLConf
 	HTTPServer HTTPServerConf
 	GRPCServer GRPCServerConf
@@ -18,6 +20,12 @@ type LoggerConf struct {
 	Level string `mapstructure:"level" default:"INFO"`
 }
 
+type CalendarConf struct {
+	FirstWeekday string `mapstructure:"firstWeekday"`
+	// ShutdownTimeout limits the wait for active requests, streams left after it are closed.
+	ShutdownTimeout time.Duration `mapstructure:"shutdownTimeout"`
+}
+
 type SQLConf struct {
 	Username       string `mapstructure:"userName"`
 	Password       string `mapstructure:"password"`
@@ -28,12 +36,14 @@ type SQLConf struct {
 }
 
 type HTTPServerConf struct {
-	Host string `mapstructure:"host" default:"0.0.0.0"`
-	Port string `mapstructure:"port" default:"8080"`
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host" default:"0.0.0.0"`
+	Port    string `mapstructure:"port" default:"8080"`
 }
 
 type GRPCServerConf struct {
-	Port string `mapstructure:"port" default:"50051"`
+	Enabled bool   `mapstructure:"enabled"`
+	Port    string `mapstructure:"port" default:"50051"`
 }
 
 func NewConfig(path string) (Config, error) {
@@ -43,6 +53,10 @@ func NewConfig(path string) (Config, error) {
 	viper.SetDefault("SQL.Host", "0.0.0.0")
 	viper.SetDefault("SQL.Port", "5435")
 	viper.SetDefault("SQL.Database", "backend")
+	viper.SetDefault("Calendar.FirstWeekday", "monday")
+	viper.SetDefault("Calendar.ShutdownTimeout", 10*time.Second)
+	viper.SetDefault("HTTPServer.Enabled", true)
+	viper.SetDefault("GRPCServer.Enabled", true)
 	viper.SetConfigFile(path)
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -4,24 +4,17 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"log"
-	"net"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
-	"time"
 
-	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/grpcserver"
-	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/pb"
-	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/http/handlers"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
 	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
 	sqlstorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/sql"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
-	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/server/http"
-	"google.golang.org/grpc"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/server/readiness"
 )
 
 const (
@@ -38,7 +31,8 @@ var (
 func init() {
 	flag.StringVar(&configFile, "config", "./configs/config.yaml", "Path to configuration file")
 	flag.StringVar(&database, "database", "memory", "What database should we use")
-	flag.StringVar(&transport, "transport", "grpc", "What transport we need to use(grpc or http)")
+	flag.StringVar(&transport, "transport", "",
+		"Run only this transport(grpc or http), transports enabled in config are run by default")
 }
 
 func main() {
@@ -81,52 +75,54 @@ func main() {
 	}
 
 	defer storage.Close()
-	calendar := app.New(logg, storage)
 
+	firstWeekday, err := app.ParseWeekday(config.Calendar.FirstWeekday)
+	if err != nil {
+		logg.Fatal("invalid first weekday of calendar", map[string]interface{}{"error": err})
+	}
+	calendar := app.New(logg, storage, firstWeekday)
+	defer calendar.Close()
+
+	httpEnabled, grpcEnabled := config.HTTPServer.Enabled, config.GRPCServer.Enabled
 	switch strings.ToLower(transport) {
+	case "":
 	case httpTransport:
-		handler := handlers.NewHandler(logg, calendar)
-		server := internalhttp.NewServer(logg, config.HTTPServer.Host, config.HTTPServer.Port, handler.InitRoutes())
-
-		go func() {
-			<-ctx.Done()
-
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-			defer cancel()
-
-			if err := server.Stop(ctx); err != nil {
-				logg.Error("failed to stop http server: "+err.Error(), nil)
-			}
-		}()
-
-		logg.Info("calendar is running...", nil)
-
-		if err := server.Start(); err != nil {
-			logg.Error("failed to start http server: "+err.Error(), nil)
-			cancel()
-			os.Exit(1) //nolint:gocritic
-		}
+		httpEnabled, grpcEnabled = true, false
 	case grpcTransport:
-		grpcService := grpcserver.NewServer(calendar, logg)
-		server := grpc.NewServer()
-		pb.RegisterEventServiceServer(server, grpcService)
+		httpEnabled, grpcEnabled = false, true
+	default:
+		logg.Fatal("unsupported type of transport", map[string]interface{}{"transport": transport})
+	}
 
-		go func() {
-			<-ctx.Done()
-			logg.Info("stopping grpc server...", nil)
-			server.GracefulStop()
-		}()
+	var components []string
+	if httpEnabled {
+		components = append(components, httpTransport)
+	}
+	if grpcEnabled {
+		components = append(components, grpcTransport)
+	}
+	if len(components) == 0 {
+		logg.Fatal("no transport is enabled", nil)
+	}
+	ready := readiness.New(components...)
 
-		lsn, err := net.Listen("tcp", fmt.Sprintf(":%s", config.GRPCServer.Port))
+	var servers []transportServer
+	if httpEnabled {
+		server, err := newHTTPServer(ctx, logg, config.HTTPServer, calendar, ready)
 		if err != nil {
-			logg.Fatal(err.Error(), nil)
+			logg.Fatal("failed to create http server", map[string]interface{}{"error": err})
 		}
+		servers = append(servers, server)
+	}
+	if grpcEnabled {
+		servers = append(servers, newGRPCServer(logg, config.GRPCServer, calendar, ready))
+	}
 
-		logg.Info("starting server on "+lsn.Addr().String(), nil)
-		if err := server.Serve(lsn); err != nil {
-			logg.Fatal("failed to start grpc server", map[string]interface{}{"error": err})
-		}
-	default:
-		logg.Fatal("unsupported type jf transport", map[string]interface{}{"transport": transport})
+	logg.Info("calendar is running...", map[string]interface{}{"transports": components})
+	if !run(ctx, logg, servers, ready, config.Calendar.ShutdownTimeout, calendar.Close) {
+		cancel()
+		calendar.Close()
+		storage.Close()
+		os.Exit(1) //nolint:gocritic
 	}
 }
@@ -0,0 +1,153 @@
+package main
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/grpcserver"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/pb"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/http/gateway"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/http/handlers"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	internalhttp "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/server/http"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/server/readiness"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// transportServer is a server of one transport sharing the calendar with the others.
+type transportServer struct {
+	name    string
+	address string
+	serve   func(listener net.Listener) error
+	// stop waits for active requests until ctx is done and closes the rest.
+	stop func(ctx context.Context) error
+}
+
+func newHTTPServer(
+	ctx context.Context, logg app.Logger, conf HTTPServerConf, calendar *app.App, ready *readiness.Readiness,
+) (transportServer, error) {
+	router := handlers.NewHandler(logg, calendar).InitRoutes()
+	router.Handle("/ready", ready).Methods(http.MethodGet)
+
+	restGateway, err := gateway.NewHandler(ctx, logg, calendar)
+	if err != nil {
+		return transportServer{}, err
+	}
+	router.PathPrefix("/v1/").Handler(restGateway)
+	server := internalhttp.NewServer(logg, conf.Host, conf.Port, router)
+
+	return transportServer{name: httpTransport, address: server.Addr(), serve: server.Serve, stop: server.Stop}, nil
+}
+
+func newGRPCServer(
+	logg app.Logger, conf GRPCServerConf, calendar *app.App, ready *readiness.Readiness,
+) transportServer {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcserver.UserIDInterceptor),
+		grpc.StreamInterceptor(grpcserver.UserIDStreamInterceptor),
+	)
+	pb.RegisterEventServiceServer(server, grpcserver.NewServer(calendar, logg))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	ready.OnChange(func(ready bool) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+	})
+
+	return transportServer{
+		name:    grpcTransport,
+		address: fmt.Sprintf(":%s", conf.Port),
+		serve:   server.Serve,
+		stop: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				// Streams like WatchEvents do not end by themselves.
+				server.Stop()
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// run serves every transport until ctx is done or one of them fails, then stops all of them together.
+// closeStreams ends streams like WatchEvents before the servers stop, they would hold graceful stops until
+// the timeout otherwise. It reports whether the transports were stopped without failures.
+func run(
+	ctx context.Context, logg app.Logger, servers []transportServer, ready *readiness.Readiness, timeout time.Duration,
+	closeStreams func(),
+) bool {
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, server := range servers {
+		listener, err := net.Listen("tcp", server.address)
+		if err != nil {
+			logg.Error("failed to listen", map[string]interface{}{"error": err, "transport": server.name})
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+			return false
+		}
+		listeners = append(listeners, listener)
+	}
+
+	errs := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(server transportServer, listener net.Listener) {
+			logg.Info("starting server on "+listener.Addr().String(), map[string]interface{}{"transport": server.name})
+			ready.Set(server.name, true)
+			err := server.serve(listener)
+			ready.Set(server.name, false)
+			if err == nil {
+				err = fmt.Errorf("%s server has stopped", server.name)
+			}
+			errs <- err
+		}(server, listeners[i])
+	}
+
+	succeeded := true
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		logg.Error("transport failed", map[string]interface{}{"error": err})
+		succeeded = false
+	}
+
+	// Probes see the process as not ready before the servers stop accepting requests.
+	ready.ShutDown()
+	logg.Info("stopping servers...", nil)
+	closeStreams()
+	stopCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server transportServer) {
+			defer wg.Done()
+			if err := server.stop(stopCtx); err != nil {
+				logg.Error("failed to stop server gracefully", map[string]interface{}{"error": err, "transport": server.name})
+			}
+		}(server)
+	}
+	wg.Wait()
+
+	return succeeded
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/grpcserver"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/pb"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/http/handlers"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/server/readiness"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRunStopsWithOpenStreams(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+
+	storage := memorystorage.New(logg)
+	defer storage.Close()
+	calendar := app.New(logg, storage, time.Monday)
+	defer calendar.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	httpPort, grpcPort := freePort(t), freePort(t)
+	ready := readiness.New(httpTransport, grpcTransport)
+	httpServer, err := newHTTPServer(ctx, logg, HTTPServerConf{Host: "127.0.0.1", Port: httpPort}, calendar, ready)
+	require.NoError(t, err)
+	servers := []transportServer{httpServer, newGRPCServer(logg, GRPCServerConf{Port: grpcPort}, calendar, ready)}
+
+	const timeout = 10 * time.Second
+	stopped := make(chan bool)
+	go func() {
+		stopped <- run(ctx, logg, servers, ready, timeout, calendar.Close)
+	}()
+
+	require.Eventually(t, ready.Ready, time.Second, 10*time.Millisecond)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+		"http://127.0.0.1:"+httpPort+"/event/stream?from=2024-01-01&to=2024-01-31", nil)
+	require.NoError(t, err)
+	req.Header.Set(handlers.UserIDHeader, "user")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	conn, err := grpc.Dial("127.0.0.1:"+grpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := pb.NewEventServiceClient(conn)
+	userCtx := metadata.AppendToOutgoingContext(context.Background(), grpcserver.UserIDMetadataKey, "user")
+	for day := 10; day <= 11; day++ {
+		_, err = client.CreateEvent(userCtx, &pb.Event{
+			Header:    "header",
+			EventTime: timestamppb.New(time.Date(2024, 1, day, 10, 0, 0, 0, time.UTC)),
+		})
+		require.NoError(t, err)
+	}
+
+	// The second change is received once the server streams it, so the stream is open on both sides.
+	stream, err := client.WatchEvents(userCtx, &pb.WatchEventsRequest{AfterRevision: 1})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	started := time.Now()
+	cancel()
+
+	select {
+	case succeeded := <-stopped:
+		require.True(t, succeeded)
+	case <-time.After(timeout):
+		t.Fatal("servers are not stopped before the shutdown timeout")
+	}
+	require.Less(t, time.Since(started), timeout/2, "servers waited for the streams to time out")
+
+	_, err = stream.Recv()
+	require.Error(t, err)
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
@@ -3,14 +3,16 @@ package main
 //nolint:depguard
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Logger LoggerConf
-	SQL    SQLConf
-	MB     MBConf
+	Logger    LoggerConf
+	SQL       SQLConf
+	MB        MBConf
+	Scheduler SchedulerConf
 }
 
 type LoggerConf struct {
@@ -26,6 +28,27 @@ type SQLConf struct {
 	MigrationsPath string `mapstructure:"migrationsPath"`
 }
 
+type SchedulerConf struct {
+	// Interval is how often due notifications are put into the outbox.
+	Interval       time.Duration `mapstructure:"interval"`
+	TrashRetention time.Duration `mapstructure:"trashRetention"`
+	Relay          RelayConf     `mapstructure:"relay"`
+	Metrics        MetricsConf   `mapstructure:"metrics"`
+}
+
+// MetricsConf is the address expvar metrics are served at /debug/vars, they are not served without a port.
+type MetricsConf struct {
+	Host string `mapstructure:"host"`
+	Port string `mapstructure:"port"`
+}
+
+type RelayConf struct {
+	Interval   time.Duration `mapstructure:"interval"`
+	MinBackoff time.Duration `mapstructure:"minBackoff"`
+	MaxBackoff time.Duration `mapstructure:"maxBackoff"`
+	StuckAfter time.Duration `mapstructure:"stuckAfter"`
+}
+
 type MBConf struct {
 	Username     string `mapstructure:"username"`
 	Password     string `mapstructure:"password"`
@@ -56,6 +79,13 @@ func NewConfig(path string) (Config, error) {
 	viper.SetDefault("MB.QueueName", "test-queue")
 	viper.SetDefault("MB.RouteKey", "test-route")
 
+	viper.SetDefault("Scheduler.Interval", time.Minute)
+	viper.SetDefault("Scheduler.TrashRetention", 30*24*time.Hour)
+	viper.SetDefault("Scheduler.Relay.Interval", 5*time.Second)
+	viper.SetDefault("Scheduler.Relay.MinBackoff", time.Second)
+	viper.SetDefault("Scheduler.Relay.MaxBackoff", 5*time.Minute)
+	viper.SetDefault("Scheduler.Relay.StuckAfter", 10*time.Minute)
+
 	viper.SetConfigFile(path)
 
 	if err := viper.ReadInConfig(); err != nil {
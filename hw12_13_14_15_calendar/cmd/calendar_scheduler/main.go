@@ -3,9 +3,11 @@ package main
 //nolint:depguard
 import (
 	"context"
+	"expvar"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os/signal"
 	"syscall"
 	"time"
@@ -14,17 +16,16 @@ import (
 	sqlstorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/sql"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/mb"
+	internalhttp "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/server/http"
 )
 
 var (
 	configFile string
-	frequency  int
 	database   string
 )
 
 func init() {
 	flag.StringVar(&configFile, "config", "./configs/scheduler_config.yaml", "Path to configuration file")
-	flag.IntVar(&frequency, "frequency", 24, "time between scheduler working(hours)")
 	flag.StringVar(&database, "database", "sql", "What database should we use")
 }
 
@@ -73,10 +74,29 @@ func main() {
 
 	producer := mb.NewProducer(broker)
 
-	schedule := scheduler.New(logg, storage, producer, time.Second*time.Duration(frequency), config.MB.RouteKey)
+	relay := scheduler.NewRelay(logg, storage, producer, config.MB.RouteKey, config.Scheduler.Relay.Interval,
+		config.Scheduler.Relay.MinBackoff, config.Scheduler.Relay.MaxBackoff, config.Scheduler.Relay.StuckAfter)
+	logg.Info("starting outbox relay...", nil)
+	go relay.Start(ctx)
+
+	schedule := scheduler.New(logg, storage, config.Scheduler.Interval, config.Scheduler.TrashRetention)
 	logg.Info("starting scheduler...", nil)
 	go schedule.Start(ctx)
 
+	if config.Scheduler.Metrics.Port != "" {
+		router := http.NewServeMux()
+		router.Handle("/debug/vars", expvar.Handler())
+		metrics := internalhttp.NewServer(logg, config.Scheduler.Metrics.Host, config.Scheduler.Metrics.Port, router)
+		go func() {
+			_ = metrics.Start()
+		}()
+		defer func() {
+			stopCtx, stop := context.WithTimeout(context.Background(), time.Second)
+			defer stop()
+			_ = metrics.Stop(stopCtx)
+		}()
+	}
+
 	<-ctx.Done()
 	time.Sleep(5 * time.Second)
 	logg.Info("closing database...", nil)
@@ -3,19 +3,32 @@ package main
 //nolint:depguard
 import (
 	"fmt"
+	"time"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/sender"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Logger LoggerConf
-	MB     MBConf
+	Logger   LoggerConf
+	SQL      SQLConf
+	MB       MBConf
+	Notifier NotifierConf
 }
 
 type LoggerConf struct {
 	Level string `mapstructure:"level" default:"INFO"`
 }
 
+type SQLConf struct {
+	Username       string `mapstructure:"userName"`
+	Password       string `mapstructure:"password"`
+	Host           string `mapstructure:"host"`
+	Port           string `mapstructure:"port"`
+	Database       string `mapstructure:"database"`
+	MigrationsPath string `mapstructure:"migrationsPath"`
+}
+
 type MBConf struct {
 	Username     string `mapstructure:"username"`
 	Password     string `mapstructure:"password"`
@@ -29,8 +42,54 @@ type MBConf struct {
 	ClientTag    string `mapstructure:"clientTag"`
 }
 
+// NotifierConf selects the channel of every user, users without a preference are notified on Channel.
+type NotifierConf struct {
+	Channel     string           `mapstructure:"channel"`
+	Preferences []PreferenceConf `mapstructure:"preferences"`
+	Template    TemplateConf     `mapstructure:"template"`
+	Email       EmailConf        `mapstructure:"email"`
+	Webhook     WebhookConf      `mapstructure:"webhook"`
+	Log         LogConf          `mapstructure:"log"`
+}
+
+type PreferenceConf struct {
+	UserID  string `mapstructure:"userId"`
+	Channel string `mapstructure:"channel"`
+	Address string `mapstructure:"address"`
+}
+
+type TemplateConf struct {
+	Subject string `mapstructure:"subject"`
+	Body    string `mapstructure:"body"`
+}
+
+type EmailConf struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+type WebhookConf struct {
+	URL     string        `mapstructure:"url"`
+	Secret  string        `mapstructure:"secret"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// LogConf is the file notifications are appended to, they are written to the standard output without it.
+type LogConf struct {
+	Path string `mapstructure:"path"`
+}
+
 func NewConfig(path string) (Config, error) {
 	var conf Config
+	viper.SetDefault("SQL.Username", "postgres")
+	viper.SetDefault("SQL.Password", "password")
+	viper.SetDefault("SQL.Host", "0.0.0.0")
+	viper.SetDefault("SQL.Port", "5435")
+	viper.SetDefault("SQL.Database", "backend")
+
 	viper.SetDefault("MB.Username", "rabbit")
 	viper.SetDefault("MB.Password", "password")
 	viper.SetDefault("MB.Host", "0.0.0.0")
@@ -42,6 +101,14 @@ func NewConfig(path string) (Config, error) {
 	viper.SetDefault("MB.RouteKey", "test-route")
 	viper.SetDefault("MB.ClientTag", "test-client")
 
+	viper.SetDefault("Notifier.Channel", "log")
+	viper.SetDefault("Notifier.Template.Subject", sender.DefaultSubject)
+	viper.SetDefault("Notifier.Template.Body", sender.DefaultBody)
+	viper.SetDefault("Notifier.Email.Host", "0.0.0.0")
+	viper.SetDefault("Notifier.Email.Port", "25")
+	viper.SetDefault("Notifier.Email.From", "calendar@localhost")
+	viper.SetDefault("Notifier.Webhook.Timeout", 10*time.Second)
+
 	viper.SetConfigFile(path)
 
 	if err := viper.ReadInConfig(); err != nil {
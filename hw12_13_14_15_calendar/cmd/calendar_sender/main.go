@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/sender"
+	sqlstorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/sql"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/mb"
 )
@@ -37,17 +38,34 @@ func main() {
 		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	defer cancel()
 
+	storage := sqlstorage.NewPostgresStorage(sqlstorage.PgConfig{
+		Host:           config.SQL.Host,
+		Username:       config.SQL.Username,
+		Password:       config.SQL.Password,
+		Port:           config.SQL.Port,
+		Database:       config.SQL.Database,
+		MigrationsPath: config.SQL.MigrationsPath,
+	}, logg, false)
+
 	connectionURL := fmt.Sprintf("%s://%s:%s@%s:%s",
 		config.MB.Protocol, config.MB.Username, config.MB.Password, config.MB.Host, config.MB.Port)
 
 	broker := mb.NewBroker(connectionURL, config.MB.ExchangeName, config.MB.ExchangeType, logg, true)
 	consumer := mb.NewConsumer(config.MB.ClientTag, broker)
 
-	notificationSender := sender.New(logg, consumer, config.MB.QueueName, config.MB.RouteKey)
+	router, closeLog, err := newRouter(config.Notifier)
+	if err != nil {
+		logg.Fatal("error while creating notifiers", map[string]interface{}{"error": err})
+	}
+	defer closeLog()
+
+	notificationSender := sender.New(logg, consumer, storage, router, config.MB.QueueName, config.MB.RouteKey)
 	logg.Info("starting notification sender...", nil)
 	go notificationSender.Start(ctx)
 
 	<-ctx.Done()
 	logg.Info("closing notification sender...", nil)
 	time.Sleep(5 * time.Second)
+	logg.Info("closing database...", nil)
+	storage.Close()
 }
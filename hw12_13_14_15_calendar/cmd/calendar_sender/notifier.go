@@ -0,0 +1,54 @@
+package main
+
+//nolint:depguard
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/sender"
+)
+
+// newRouter builds the notifiers of every channel, the returned function closes the file of the log notifier.
+func newRouter(conf NotifierConf) (*sender.Router, func(), error) {
+	template, err := sender.NewTemplate(conf.Template.Subject, conf.Template.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out io.Writer = os.Stdout
+	closeLog := func() {}
+	if conf.Log.Path != "" {
+		var file *os.File
+		file, err = os.OpenFile(conf.Log.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while opening notification log: %w", err)
+		}
+		out = file
+		closeLog = func() {
+			_ = file.Close()
+		}
+	}
+
+	notifiers := map[string]sender.Notifier{
+		"log": sender.NewLogNotifier(out, template),
+		"email": sender.NewEmailNotifier(
+			conf.Email.Host, conf.Email.Port, conf.Email.Username, conf.Email.Password, conf.Email.From, template),
+		"webhook": sender.NewWebhookNotifier(conf.Webhook.URL, conf.Webhook.Secret, conf.Webhook.Timeout, template),
+	}
+
+	preferences := make(map[string]sender.Preference, len(conf.Preferences))
+	for _, preference := range conf.Preferences {
+		if _, ok := notifiers[preference.Channel]; !ok {
+			return nil, nil, fmt.Errorf("%w %q of user %s", sender.ErrUnknownChannel, preference.Channel, preference.UserID)
+		}
+
+		preferences[preference.UserID] = sender.Preference{Channel: preference.Channel, Address: preference.Address}
+	}
+
+	if _, ok := notifiers[conf.Channel]; !ok {
+		return nil, nil, fmt.Errorf("%w %q", sender.ErrUnknownChannel, conf.Channel)
+	}
+
+	return sender.NewRouter(notifiers, preferences, sender.Preference{Channel: conf.Channel}), closeLog, nil
+}
@@ -0,0 +1,56 @@
+package grpcserver
+
+//nolint:depguard
+import (
+	"errors"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatusError converts domain errors of the application into gRPC status errors,
+// invalid fields of validation errors are sent as BadRequest details.
+func toStatusError(err error) error {
+	st := status.New(codeFromError(err), err.Error())
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		badRequest := &errdetails.BadRequest{}
+		for _, validationErr := range validationErrors {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       validationErr.Field,
+				Description: validationErr.Err.Error(),
+			})
+		}
+
+		if detailed, detailsErr := st.WithDetails(badRequest); detailsErr == nil {
+			st = detailed
+		}
+	}
+
+	return st.Err()
+}
+
+func codeFromError(err error) codes.Code {
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, app.ErrInvalidArgument):
+		return codes.InvalidArgument
+	case errors.Is(err, app.ErrConflict):
+		return codes.AlreadyExists
+	case errors.Is(err, app.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, app.ErrUnauthenticated):
+		return codes.Unauthenticated
+	case errors.Is(err, app.ErrPreconditionFailed):
+		return codes.FailedPrecondition
+	case errors.Is(err, app.ErrAborted):
+		return codes.Aborted
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,54 @@
+package grpcserver
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// IfMatchMetadataKey carries the expected version of the event as an entity tag like the If-Match header.
+	IfMatchMetadataKey = "if-match"
+	// ETagMetadataKey is the header with the version of the returned event as an entity tag.
+	ETagMetadataKey = "etag"
+)
+
+// expectedVersion returns the version of the request, without it the version of If-Match metadata.
+func expectedVersion(ctx context.Context, version int64) (int64, error) {
+	if version != 0 {
+		return version, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(IfMatchMetadataKey)
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	ifMatch := strings.TrimSpace(values[0])
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return 0, status.Errorf(codes.InvalidArgument, "%s must be a single entity tag of the event", IfMatchMetadataKey)
+	}
+
+	return version, nil
+}
+
+// setETag sends the version of the event as a strong entity tag in the header of the response.
+func setETag(ctx context.Context, version int64) {
+	if version != 0 {
+		// Calls made without a server stream, like those of tests, have no header to set.
+		_ = grpc.SetHeader(ctx, metadata.Pairs(ETagMetadataKey, fmt.Sprintf(`"%d"`, version)))
+	}
+}
@@ -0,0 +1,41 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	ifMatch := func(value string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(IfMatchMetadataKey, value))
+	}
+
+	testTable := []struct {
+		name            string
+		ctx             context.Context
+		version         int64
+		expectedVersion int64
+		expectedCode    codes.Code
+	}{
+		{name: "without metadata", ctx: context.Background()},
+		{name: "version of request", ctx: ifMatch(`"7"`), version: 3, expectedVersion: 3},
+		{name: "entity tag", ctx: ifMatch(`"7"`), expectedVersion: 7},
+		{name: "any version", ctx: ifMatch("*")},
+		{name: "weak entity tag", ctx: ifMatch(`W/"7"`), expectedCode: codes.InvalidArgument},
+		{name: "not a version", ctx: ifMatch(`"seven"`), expectedCode: codes.InvalidArgument},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			version, err := expectedVersion(testCase.ctx, testCase.version)
+
+			require.Equal(t, testCase.expectedCode, status.Code(err))
+			require.Equal(t, testCase.expectedVersion, version)
+		})
+	}
+}
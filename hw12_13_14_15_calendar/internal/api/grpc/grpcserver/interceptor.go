@@ -0,0 +1,60 @@
+package grpcserver
+
+//nolint:depguard
+import (
+	"context"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const UserIDMetadataKey = "user-id"
+
+func UserIDInterceptor(
+	ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	userID, err := userIDFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(app.ContextWithUserID(ctx, userID), req)
+}
+
+// UserIDStreamInterceptor is UserIDInterceptor of streaming calls.
+func UserIDStreamInterceptor(
+	srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	userID, err := userIDFromMetadata(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &userIDStream{ServerStream: stream, ctx: app.ContextWithUserID(stream.Context(), userID)})
+}
+
+type userIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *userIDStream) Context() context.Context {
+	return s.ctx
+}
+
+func userIDFromMetadata(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "%s metadata is required", UserIDMetadataKey)
+	}
+
+	values := md.Get(UserIDMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return "", status.Errorf(codes.Unauthenticated, "%s metadata is required", UserIDMetadataKey)
+	}
+
+	return values[0], nil
+}
@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUserIDInterceptor(t *testing.T) {
+	testTable := []struct {
+		name           string
+		ctx            context.Context
+		expectedUserID string
+		expectedCode   codes.Code
+	}{
+		{
+			name:           "successful",
+			ctx:            metadata.NewIncomingContext(context.Background(), metadata.Pairs(UserIDMetadataKey, "user")),
+			expectedUserID: "user",
+			expectedCode:   codes.OK,
+		},
+		{
+			name:         "without metadata",
+			ctx:          context.Background(),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "without user id",
+			ctx:          metadata.NewIncomingContext(context.Background(), metadata.Pairs("other", "value")),
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var userID string
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				userID, _ = app.UserIDFromContext(ctx)
+				return nil, nil
+			}
+
+			_, err := UserIDInterceptor(testCase.ctx, nil, &grpc.UnaryServerInfo{}, handler)
+
+			require.Equal(t, testCase.expectedCode, status.Code(err))
+			require.Equal(t, testCase.expectedUserID, userID)
+		})
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUserIDStreamInterceptor(t *testing.T) {
+	var userID string
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		userID, _ = app.UserIDFromContext(stream.Context())
+		return nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(UserIDMetadataKey, "user"))
+	err := UserIDStreamInterceptor(nil, &contextStream{ctx: ctx}, &grpc.StreamServerInfo{}, handler)
+	require.NoError(t, err)
+	require.Equal(t, "user", userID)
+
+	err = UserIDStreamInterceptor(nil, &contextStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -3,13 +3,21 @@ package grpcserver
 //nolint:depguard
 import (
 	"context"
-	"fmt"
+	"errors"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/pb"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -27,62 +35,441 @@ func NewServer(service api.ApplicationInterface, logger app.Logger) *Server {
 }
 
 func (s *Server) CreateEvent(ctx context.Context, req *pb.Event) (*pb.CreateEventResponse, error) {
-	serviceEvent := models.Event{
-		Header:      req.Header,
-		Description: req.Description,
-		UserID:      req.UserID,
+	serviceEvent, err := s.convertEvent(req)
+	if err != nil {
+		return nil, err
 	}
+	serviceEvent.ID = ""
 
-	if req.EventTime != nil {
-		eventTime := req.EventTime.AsTime()
-		valid := req.EventTime.IsValid()
-		switch valid {
-		case false:
-			s.logger.Error("invalid event time", map[string]interface{}{"event time": eventTime})
-			return nil, fmt.Errorf("invalid eventTime:%v", eventTime)
-		default:
-			serviceEvent.EventTime = eventTime
+	result, err := s.service.CreateEvent(ctx, serviceEvent)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.CreateEventResponse{Id: result}, nil
+}
+
+// UpdateEvent replaces the event, the expected version is the Version of the event or the If-Match metadata.
+func (s *Server) UpdateEvent(ctx context.Context, req *pb.Event) (*empty.Empty, error) {
+	serviceEvent, err := s.convertEvent(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceEvent.Version, err = expectedVersion(ctx, serviceEvent.Version); err != nil {
+		return nil, err
+	}
+
+	err = s.service.UpdateEvent(ctx, serviceEvent)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	// An update makes the next version of the expected one.
+	if serviceEvent.Version != 0 {
+		setETag(ctx, serviceEvent.Version+1)
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// PatchEvent changes the fields listed in the update mask, paths are names of Event fields.
+// Over REST the body is the event as a JSON merge patch, the mask is made from its keys unless it is given.
+func (s *Server) PatchEvent(ctx context.Context, req *pb.PatchEventRequest) (*pb.Event, error) {
+	if req.Event == nil {
+		return nil, status.Error(codes.InvalidArgument, "not specified event")
+	}
+
+	if len(req.UpdateMask.GetPaths()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "not specified update mask")
+	}
+
+	serviceEvent, err := s.convertEvent(req.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceEvent.Version, err = expectedVersion(ctx, serviceEvent.Version); err != nil {
+		return nil, err
+	}
+
+	patch := models.EventPatch{Event: serviceEvent}
+	for _, path := range req.UpdateMask.Paths {
+		// The version is the expected one, a mask made from the keys of a REST body may list it.
+		if field := fieldName(path); field != "version" {
+			patch.Fields = append(patch.Fields, field)
 		}
 	}
 
-	if req.FinishEventTime != nil {
-		finishTime := req.FinishEventTime.AsTime()
-		valid := req.FinishEventTime.IsValid()
-		switch valid {
-		case false:
-			s.logger.Error("invalid finish time", map[string]interface{}{"finish time": finishTime})
-			return nil, fmt.Errorf("invalid finishTime:%v", finishTime)
-		default:
-			serviceEvent.FinishEventTime = &finishTime
+	event, err := s.service.PatchEvent(ctx, req.Event.ID, patch)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	setETag(ctx, event.Version)
+
+	return convert(event), nil
+}
+
+func (s *Server) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*empty.Empty, error) {
+	version, err := expectedVersion(ctx, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.service.DeleteEvent(ctx, req.Id, version)
+	if err != nil {
+		return &empty.Empty{}, toStatusError(err)
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// BatchEvents collects items of the whole stream and applies them as one batch.
+func (s *Server) BatchEvents(stream pb.EventService_BatchEventsServer) error {
+	var items []models.BatchItem
+	var atomic bool
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			s.logger.Error("error while receiving batch", map[string]interface{}{"error": err})
+			return err
+		}
+
+		atomic = atomic || req.Atomic
+		for _, pbItem := range req.Items {
+			if len(items) == app.MaxBatchSize {
+				return status.Errorf(codes.InvalidArgument, "batch must not contain more than %d items", app.MaxBatchSize)
+			}
+
+			pbEvent := pbItem.GetEvent()
+			if pbEvent == nil {
+				pbEvent = &pb.Event{}
+			}
+
+			event, err := s.convertEvent(pbEvent)
+			if err != nil {
+				return err
+			}
+
+			items = append(items, models.BatchItem{Operation: models.BatchOperation(pbItem.Operation), Event: event})
 		}
 	}
 
-	if req.NotificationTime != nil {
-		notificationTime := req.NotificationTime.AsTime()
-		valid := req.NotificationTime.IsValid()
-		switch valid {
-		case false:
-			s.logger.Error("invalid notification time", map[string]interface{}{"notification time": notificationTime})
-			return nil, fmt.Errorf("invalid finishTime:%v", notificationTime)
-		default:
-			serviceEvent.NotificationTime = &notificationTime
+	results, err := s.service.ApplyBatch(stream.Context(), items, atomic)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	pbResults := make([]*pb.BatchResult, 0, len(results))
+	for _, result := range results {
+		pbResult := &pb.BatchResult{Id: result.ID, Version: result.Event.Version, Code: int32(codes.OK)}
+		if result.Err != nil {
+			pbResult.Code, pbResult.Error = int32(codeFromError(result.Err)), result.Err.Error()
 		}
+
+		pbResults = append(pbResults, pbResult)
 	}
 
-	result, err := s.service.CreateEvent(ctx, serviceEvent)
+	return stream.SendAndClose(&pb.BatchEventsResponse{Results: pbResults})
+}
+
+func (s *Server) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.Event, error) {
+	event, err := s.service.GetEvent(ctx, req.Id)
 	if err != nil {
-		return nil, fmt.Errorf("error while creating event:%w", err)
+		return nil, toStatusError(err)
 	}
+	setETag(ctx, event.Version)
 
-	return &pb.CreateEventResponse{Id: result}, nil
+	return convert(event), nil
 }
 
-func (s *Server) UpdateEvent(ctx context.Context, req *pb.Event) (*empty.Empty, error) {
+func (s *Server) GetTrash(ctx context.Context, _ *empty.Empty) (*pb.GetListEventsResponse, error) {
+	events, err := s.service.GetTrash(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return convertList(events), nil
+}
+
+func (s *Server) RestoreEvent(ctx context.Context, req *pb.RestoreEventRequest) (*pb.Event, error) {
+	event, err := s.service.RestoreEvent(ctx, req.Id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	setETag(ctx, event.Version)
+
+	return convert(event), nil
+}
+
+func (s *Server) GetEventHistory(ctx context.Context, req *pb.GetEventRequest) (*pb.GetEventHistoryResponse, error) {
+	entries, err := s.service.GetEventHistory(ctx, req.Id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	pbEntries := make([]*pb.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		pbEntry := &pb.AuditEntry{
+			Id:      entry.ID,
+			EventID: entry.EventID,
+			UserID:  entry.UserID,
+			Actor:   entry.Actor,
+			Action:  string(entry.Action),
+			Time:    timestamppb.New(entry.Time),
+		}
+
+		if entry.Before != nil {
+			pbEntry.Before = convert(*entry.Before)
+		}
+
+		if entry.After != nil {
+			pbEntry.After = convert(*entry.After)
+		}
+
+		pbEntries = append(pbEntries, pbEntry)
+	}
+
+	return &pb.GetEventHistoryResponse{Entries: pbEntries}, nil
+}
+
+// WatchEvents streams changes until the client goes away. The client resumes with the revision
+// of the last received change, when the stream ends with Unavailable.
+func (s *Server) WatchEvents(req *pb.WatchEventsRequest, stream pb.EventService_WatchEventsServer) error {
+	changes, err := s.service.WatchEvents(stream.Context(), req.AfterRevision)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	for change := range changes {
+		err := stream.Send(&pb.EventChange{
+			Revision: change.Revision,
+			Type:     string(change.Type),
+			Event:    convert(change.Event),
+			Time:     timestamppb.New(change.Time),
+		})
+		if err != nil {
+			s.logger.Error("error while sending change", map[string]interface{}{"error": err})
+			return err
+		}
+	}
+
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return status.Error(codes.Unavailable, "watching of changes was interrupted")
+}
+
+func (s *Server) GetListEvents(ctx context.Context, req *pb.GetListEventsRequest) (*pb.GetListEventsResponse, error) {
+	if req.Start == nil {
+		return nil, status.Error(codes.InvalidArgument, "not specified start date")
+	}
+
+	start := req.Start.AsTime()
+	valid := req.Start.IsValid()
+	switch valid {
+	case false:
+		s.logger.Error("invalid start time", map[string]interface{}{"start time": start})
+		return nil, status.Errorf(codes.InvalidArgument, "invalid startTime:%v", start)
+	default:
+	}
+
+	loc, err := s.location(req.Tz)
+	if err != nil {
+		return nil, err
+	}
+	start = start.In(loc)
+
+	if req.Limit != 0 || req.PageToken != "" {
+		page, err := s.service.GetListEventsPage(ctx, start, int(req.AmountDays), int(req.Limit), req.PageToken)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+
+		response := convertList(page.Events)
+		response.NextPageToken = page.NextPageToken
+
+		return response, nil
+	}
+
+	var events []models.Event
+	switch req.AmountDays {
+	case 0:
+		events, err = s.service.GetListEventsDuringDay(ctx, start)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+	default:
+		events, err = s.service.GetListEventsDuringFewDays(ctx, start, int(req.AmountDays))
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+	}
+
+	return convertList(events), nil
+}
+
+// GetListEventsByWeek returns events of the week containing the date or of the ISO week if it is given.
+func (s *Server) GetListEventsByWeek(
+	ctx context.Context, req *pb.GetListEventsByPeriodRequest,
+) (*pb.GetListEventsResponse, error) {
+	day, err := s.weekDate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.service.GetListEventsDuringWeek(ctx, day)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return convertList(events), nil
+}
+
+func (s *Server) GetListEventsByMonth(
+	ctx context.Context, req *pb.GetListEventsByPeriodRequest,
+) (*pb.GetListEventsResponse, error) {
+	day, err := s.periodDate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.service.GetListEventsDuringMonth(ctx, day)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return convertList(events), nil
+}
+
+func (s *Server) SearchEvents(ctx context.Context, req *pb.SearchEventsRequest) (*pb.GetListEventsResponse, error) {
+	query := models.SearchQuery{Text: req.Query, UserID: req.UserID, Limit: int(req.Limit)}
+
+	if req.From != nil {
+		if !req.From.IsValid() {
+			s.logger.Error("invalid from time", map[string]interface{}{"from": req.From.AsTime()})
+			return nil, status.Errorf(codes.InvalidArgument, "invalid from:%v", req.From.AsTime())
+		}
+		query.From = req.From.AsTime()
+	}
+
+	if req.To != nil {
+		if !req.To.IsValid() {
+			s.logger.Error("invalid to time", map[string]interface{}{"to": req.To.AsTime()})
+			return nil, status.Errorf(codes.InvalidArgument, "invalid to:%v", req.To.AsTime())
+		}
+		query.To = req.To.AsTime()
+	}
+
+	events, err := s.service.SearchEvents(ctx, query)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return convertList(events), nil
+}
+
+func (s *Server) periodDate(req *pb.GetListEventsByPeriodRequest) (time.Time, error) {
+	if req.Date == nil {
+		return time.Time{}, status.Error(codes.InvalidArgument, "not specified date")
+	}
+
+	date := req.Date.AsTime()
+	if !req.Date.IsValid() {
+		s.logger.Error("invalid date", map[string]interface{}{"date": date})
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "invalid date:%v", date)
+	}
+
+	loc, err := s.location(req.Tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date.In(loc), nil
+}
+
+// weekDate returns the Monday of the ISO week of the request, without the week the date of the request.
+func (s *Server) weekDate(req *pb.GetListEventsByPeriodRequest) (time.Time, error) {
+	if req.IsoWeek == 0 && req.IsoYear == 0 {
+		return s.periodDate(req)
+	}
+
+	loc, err := s.location(req.Tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	day, err := app.ISOWeekStart(int(req.IsoYear), int(req.IsoWeek), loc)
+	if err != nil {
+		return time.Time{}, toStatusError(err)
+	}
+
+	return day, nil
+}
+
+// location resolves IANA time zone of a request, UTC is used by default.
+func (s *Server) location(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		s.logger.Error("invalid time zone", map[string]interface{}{"tz": tz, "error": err})
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tz:%v", err)
+	}
+
+	return loc, nil
+}
+
+func (s *Server) ExportEvents(ctx context.Context, req *pb.GetListEventsRequest) (*httpbody.HttpBody, error) {
+	if req.Start == nil {
+		return nil, status.Error(codes.InvalidArgument, "not specified start date")
+	}
+
+	start := req.Start.AsTime()
+	if !req.Start.IsValid() {
+		s.logger.Error("invalid start time", map[string]interface{}{"start time": start})
+		return nil, status.Errorf(codes.InvalidArgument, "invalid startTime:%v", start)
+	}
+
+	loc, err := s.location(req.Tz)
+	if err != nil {
+		return nil, err
+	}
+	start = start.In(loc)
+
+	calendar, err := s.service.ExportEvents(ctx, start, int(req.AmountDays))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &httpbody.HttpBody{ContentType: ical.ContentType, Data: calendar}, nil
+}
+
+func (s *Server) ImportEvents(ctx context.Context, req *pb.ImportEventsRequest) (*pb.ImportEventsResponse, error) {
+	ids, err := s.service.ImportEvents(ctx, req.Calendar)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.ImportEventsResponse{Ids: ids}, nil
+}
+
+func (s *Server) convertEvent(req *pb.Event) (models.Event, error) {
 	serviceEvent := models.Event{
 		ID:          req.ID,
 		Header:      req.Header,
 		Description: req.Description,
 		UserID:      req.UserID,
+		Recurrence:  req.Recurrence,
+		TimeZone:    req.TimeZone,
+		Version:     req.Version,
 	}
 
 	if req.EventTime != nil {
@@ -91,7 +478,7 @@ func (s *Server) UpdateEvent(ctx context.Context, req *pb.Event) (*empty.Empty,
 		switch valid {
 		case false:
 			s.logger.Error("invalid event time", map[string]interface{}{"event time": eventTime})
-			return nil, fmt.Errorf("invalid eventTime:%v", eventTime)
+			return models.Event{}, status.Errorf(codes.InvalidArgument, "invalid eventTime:%v", eventTime)
 		default:
 			serviceEvent.EventTime = eventTime
 		}
@@ -103,7 +490,7 @@ func (s *Server) UpdateEvent(ctx context.Context, req *pb.Event) (*empty.Empty,
 		switch valid {
 		case false:
 			s.logger.Error("invalid finish time", map[string]interface{}{"finish time": finishTime})
-			return nil, fmt.Errorf("invalid finishTime:%v", finishTime)
+			return models.Event{}, status.Errorf(codes.InvalidArgument, "invalid finishTime:%v", finishTime)
 		default:
 			serviceEvent.FinishEventTime = &finishTime
 		}
@@ -115,65 +502,47 @@ func (s *Server) UpdateEvent(ctx context.Context, req *pb.Event) (*empty.Empty,
 		switch valid {
 		case false:
 			s.logger.Error("invalid notification time", map[string]interface{}{"notification time": notificationTime})
-			return nil, fmt.Errorf("invalid finishTime:%v", notificationTime)
+			return models.Event{}, status.Errorf(codes.InvalidArgument, "invalid finishTime:%v", notificationTime)
 		default:
 			serviceEvent.NotificationTime = &notificationTime
 		}
 	}
 
-	err := s.service.UpdateEvent(ctx, serviceEvent)
-	if err != nil {
-		return nil, err
+	if req.RemindBefore != nil {
+		if err := req.RemindBefore.CheckValid(); err != nil {
+			s.logger.Error("invalid remind before", map[string]interface{}{"error": err})
+			return models.Event{}, status.Errorf(codes.InvalidArgument, "invalid remindBefore:%v", err)
+		}
+		serviceEvent.RemindBefore = models.Duration(req.RemindBefore.AsDuration())
 	}
 
-	return &empty.Empty{}, nil
-}
-
-func (s *Server) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*empty.Empty, error) {
-	err := s.service.DeleteEvent(ctx, req.Id)
+	exDates, err := s.convertExDates(req.ExDates)
 	if err != nil {
-		return &empty.Empty{}, err
+		return models.Event{}, err
 	}
+	serviceEvent.ExDates = exDates
 
-	return &empty.Empty{}, nil
+	return serviceEvent, nil
 }
 
-func (s *Server) GetListEvents(ctx context.Context, req *pb.GetListEventsRequest) (*pb.GetListEventsResponse, error) {
-	if req.Start == nil {
-		return nil, fmt.Errorf("not specified start date")
-	}
-
-	start := req.Start.AsTime()
-	valid := req.Start.IsValid()
-	switch valid {
-	case false:
-		s.logger.Error("invalid start time", map[string]interface{}{"start time": start})
-		return nil, fmt.Errorf("invalid startTime:%v", start)
-	default:
-	}
-
-	var events []models.Event
-	var err error
-	switch req.AmountDays {
-	case 0:
-		events, err = s.service.GetListEventsDuringDay(ctx, start)
-		if err != nil {
-			return nil, err
+// fieldName turns a path of the update mask into the JSON name of the field, "EventTime" into "eventTime".
+// Masks decoded from JSON by the gateway have snake case paths, "eventTime" comes as "event_time".
+func fieldName(path string) string {
+	var name strings.Builder
+	for _, part := range strings.Split(path, "_") {
+		if part == "" {
+			continue
 		}
-	default:
-		events, err = s.service.GetListEventsDuringFewDays(ctx, start, int(req.AmountDays))
-		if err != nil {
-			return nil, err
-		}
-	}
 
-	pbEvents := make([]*pb.Event, 0, len(events))
-
-	for _, event := range events {
-		pbEvents = append(pbEvents, convert(event))
+		if name.Len() == 0 {
+			name.WriteString(strings.ToLower(part[:1]))
+		} else {
+			name.WriteString(strings.ToUpper(part[:1]))
+		}
+		name.WriteString(part[1:])
 	}
 
-	return &pb.GetListEventsResponse{Events: pbEvents}, nil
+	return name.String()
 }
 
 func convert(event models.Event) *pb.Event {
@@ -183,6 +552,13 @@ func convert(event models.Event) *pb.Event {
 		Description: event.Description,
 		UserID:      event.UserID,
 		EventTime:   timestamppb.New(event.EventTime),
+		Recurrence:  event.Recurrence,
+		TimeZone:    event.TimeZone,
+		Version:     event.Version,
+	}
+
+	for _, exDate := range event.ExDates {
+		pbEvent.ExDates = append(pbEvent.ExDates, timestamppb.New(exDate))
 	}
 
 	if event.FinishEventTime != nil {
@@ -193,5 +569,41 @@ func convert(event models.Event) *pb.Event {
 		pbEvent.NotificationTime = timestamppb.New(*event.NotificationTime)
 	}
 
+	if event.DeletedAt != nil {
+		pbEvent.DeletedAt = timestamppb.New(*event.DeletedAt)
+	}
+
+	if event.RemindBefore != 0 {
+		pbEvent.RemindBefore = durationpb.New(time.Duration(event.RemindBefore))
+	}
+
 	return pbEvent
 }
+
+func convertList(events []models.Event) *pb.GetListEventsResponse {
+	pbEvents := make([]*pb.Event, 0, len(events))
+
+	for _, event := range events {
+		pbEvents = append(pbEvents, convert(event))
+	}
+
+	return &pb.GetListEventsResponse{Events: pbEvents}
+}
+
+func (s *Server) convertExDates(pbExDates []*timestamppb.Timestamp) ([]time.Time, error) {
+	if len(pbExDates) == 0 {
+		return nil, nil
+	}
+
+	exDates := make([]time.Time, 0, len(pbExDates))
+	for _, pbExDate := range pbExDates {
+		if !pbExDate.IsValid() {
+			s.logger.Error("invalid exception date", map[string]interface{}{"exception date": pbExDate.AsTime()})
+			return nil, status.Errorf(codes.InvalidArgument, "invalid exDate:%v", pbExDate.AsTime())
+		}
+
+		exDates = append(exDates, pbExDate.AsTime())
+	}
+
+	return exDates, nil
+}
@@ -3,16 +3,26 @@ package grpcserver
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/Baraulia/X-Labs_Test/pkg/logger"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/pb"
 	mockservice "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/mocks"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -23,6 +33,7 @@ func TestCreateEvent(t *testing.T) {
 	ctx := context.Background()
 	newUserUUID := uuid.New().String()
 	newEventUUID := uuid.New().String()
+	exDate := time.Date(2024, time.January, 8, 10, 0, 0, 0, time.UTC)
 
 	testTable := []struct {
 		name           string
@@ -50,6 +61,28 @@ func TestCreateEvent(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "successful recurring",
+			inputData: &pb.Event{
+				Header:      "header",
+				Description: "desc",
+				UserID:      newUserUUID,
+				Recurrence:  "FREQ=WEEKLY;BYDAY=MO",
+				ExDates:     []*timestamppb.Timestamp{timestamppb.New(exDate)},
+			},
+			convertData: models.Event{
+				Header:      "header",
+				Description: "desc",
+				UserID:      newUserUUID,
+				Recurrence:  "FREQ=WEEKLY;BYDAY=MO",
+				ExDates:     []time.Time{exDate},
+			},
+			expectedResult: &pb.CreateEventResponse{Id: newEventUUID},
+			mockBehavior: func(s *mockservice.MockApplicationInterface, dto models.Event) {
+				s.EXPECT().CreateEvent(ctx, dto).Return(newEventUUID, nil)
+			},
+			expectedError: false,
+		},
 		{
 			name: "error from service",
 			inputData: &pb.Event{
@@ -101,6 +134,7 @@ func TestUpdateUser(t *testing.T) {
 		inputData     *pb.Event
 		convertData   models.Event
 		mockBehavior  mockBehavior
+		expectedCode  codes.Code
 		expectedError bool
 	}{
 		{
@@ -142,6 +176,26 @@ func TestUpdateUser(t *testing.T) {
 
 			expectedError: true,
 		},
+		{
+			name: "date busy",
+			inputData: &pb.Event{
+				ID:          newEventUUID,
+				Header:      "header",
+				Description: "desc",
+				UserID:      newUserUUID,
+			},
+			convertData: models.Event{
+				ID:          newEventUUID,
+				Header:      "header",
+				Description: "desc",
+				UserID:      newUserUUID,
+			},
+			mockBehavior: func(s *mockservice.MockApplicationInterface, dto models.Event) {
+				s.EXPECT().UpdateEvent(ctx, dto).Return(app.ErrDateBusy)
+			},
+			expectedCode:  codes.AlreadyExists,
+			expectedError: true,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -155,6 +209,9 @@ func TestUpdateUser(t *testing.T) {
 			_, err = server.UpdateEvent(ctx, testCase.inputData)
 			if testCase.expectedError {
 				require.Error(t, err)
+				if testCase.expectedCode != codes.OK {
+					require.Equal(t, testCase.expectedCode, status.Code(err))
+				}
 			} else {
 				require.NoError(t, err)
 			}
@@ -174,6 +231,7 @@ func TestDeleteUser(t *testing.T) {
 		inputData     *pb.DeleteEventRequest
 		id            string
 		mockBehavior  mockBehavior
+		expectedCode  codes.Code
 		expectedError bool
 	}{
 		{
@@ -183,7 +241,7 @@ func TestDeleteUser(t *testing.T) {
 			},
 			id: newUUID,
 			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
-				s.EXPECT().DeleteEvent(ctx, id).Return(nil)
+				s.EXPECT().DeleteEvent(ctx, id, int64(0)).Return(nil)
 			},
 			expectedError: false,
 		},
@@ -194,8 +252,33 @@ func TestDeleteUser(t *testing.T) {
 			},
 			id: newUUID,
 			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
-				s.EXPECT().DeleteEvent(ctx, id).Return(errors.New("service error"))
+				s.EXPECT().DeleteEvent(ctx, id, int64(0)).Return(errors.New("service error"))
 			},
+			expectedCode:  codes.Internal,
+			expectedError: true,
+		},
+		{
+			name: "not found",
+			inputData: &pb.DeleteEventRequest{
+				Id: newUUID,
+			},
+			id: newUUID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().DeleteEvent(ctx, id, int64(0)).Return(app.ErrEventNotFound)
+			},
+			expectedCode:  codes.NotFound,
+			expectedError: true,
+		},
+		{
+			name: "event of another user",
+			inputData: &pb.DeleteEventRequest{
+				Id: newUUID,
+			},
+			id: newUUID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().DeleteEvent(ctx, id, int64(0)).Return(app.ErrEventForbidden)
+			},
+			expectedCode:  codes.PermissionDenied,
 			expectedError: true,
 		},
 	}
@@ -211,6 +294,7 @@ func TestDeleteUser(t *testing.T) {
 			_, err = server.DeleteEvent(ctx, testCase.inputData)
 			if testCase.expectedError {
 				require.Error(t, err)
+				require.Equal(t, testCase.expectedCode, status.Code(err))
 			} else {
 				require.NoError(t, err)
 			}
@@ -218,6 +302,79 @@ func TestDeleteUser(t *testing.T) {
 	}
 }
 
+func TestGetEvent(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface, id string)
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTimeForJSON := time.Now().In(time.UTC).Format(time.RFC3339Nano)
+	testTime, _ := time.Parse(time.RFC3339Nano, testTimeForJSON)
+	newUUID := uuid.New().String()
+	newUserUUID := uuid.New().String()
+
+	testTable := []struct {
+		name           string
+		inputData      *pb.GetEventRequest
+		expectedResult *pb.Event
+		mockBehavior   mockBehavior
+		expectedCode   codes.Code
+	}{
+		{
+			name:      "successful",
+			inputData: &pb.GetEventRequest{Id: newUUID},
+			expectedResult: &pb.Event{
+				ID:          newUUID,
+				Header:      "header",
+				Description: "desc",
+				UserID:      newUserUUID,
+				EventTime:   timestamppb.New(testTime),
+			},
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().GetEvent(ctx, id).Return(models.Event{
+					ID:          id,
+					Header:      "header",
+					Description: "desc",
+					UserID:      newUserUUID,
+					EventTime:   testTime,
+				}, nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:      "not found",
+			inputData: &pb.GetEventRequest{Id: newUUID},
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().GetEvent(ctx, id).Return(models.Event{}, app.ErrEventNotFound)
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:      "event of another user",
+			inputData: &pb.GetEventRequest{Id: newUUID},
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().GetEvent(ctx, id).Return(models.Event{}, app.ErrEventForbidden)
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			service := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(service, testCase.inputData.Id)
+			server := NewServer(service, logg)
+
+			response, err := server.GetEvent(ctx, testCase.inputData)
+			require.Equal(t, testCase.expectedCode, status.Code(err))
+			if testCase.expectedCode == codes.OK {
+				require.Equal(t, testCase.expectedResult, response)
+			}
+		})
+	}
+}
+
 func TestGetUsers(t *testing.T) {
 	type mockBehavior func(s *mockservice.MockApplicationInterface, start time.Time, amountDays int)
 	logg, err := logger.GetLogger("INFO")
@@ -318,3 +475,410 @@ func TestGetUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestGetListEventsByPeriod(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+	expected := &pb.GetListEventsResponse{
+		Events: []*pb.Event{{ID: newUUID, Header: "header", EventTime: timestamppb.New(testTime)}},
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().GetListEventsDuringWeek(ctx, testTime).
+		Return([]models.Event{{ID: newUUID, Header: "header", EventTime: testTime}}, nil)
+	service.EXPECT().GetListEventsDuringMonth(ctx, testTime).Return(nil, app.ErrUserNotSpecified)
+	monday := time.Date(2024, time.February, 26, 0, 0, 0, 0, time.UTC)
+	service.EXPECT().GetListEventsDuringWeek(ctx, monday).Return(nil, nil)
+	server := NewServer(service, logg)
+
+	response, err := server.GetListEventsByWeek(ctx, &pb.GetListEventsByPeriodRequest{Date: timestamppb.New(testTime)})
+	require.NoError(t, err)
+	require.Equal(t, expected, response)
+
+	_, err = server.GetListEventsByMonth(ctx, &pb.GetListEventsByPeriodRequest{Date: timestamppb.New(testTime)})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = server.GetListEventsByWeek(ctx, &pb.GetListEventsByPeriodRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.GetListEventsByWeek(ctx, &pb.GetListEventsByPeriodRequest{IsoYear: 2024, IsoWeek: 9})
+	require.NoError(t, err)
+
+	_, err = server.GetListEventsByWeek(ctx, &pb.GetListEventsByPeriodRequest{IsoYear: 2021, IsoWeek: 53})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.GetListEventsByWeek(ctx, &pb.GetListEventsByPeriodRequest{
+		Date: timestamppb.New(testTime),
+		Tz:   "Mars/Olympus",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetListEventsInTimeZone(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	// It is already the 15th of February in Tokyo.
+	testTime := time.Date(2024, time.February, 14, 20, 0, 0, 0, time.UTC)
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().GetListEventsDuringDay(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, day time.Time) ([]models.Event, error) {
+			require.Equal(t, "Asia/Tokyo", day.Location().String())
+			require.Equal(t, 15, day.Day())
+
+			return nil, nil
+		})
+	server := NewServer(service, logg)
+
+	_, err = server.GetListEvents(ctx, &pb.GetListEventsRequest{Start: timestamppb.New(testTime), Tz: "Asia/Tokyo"})
+	require.NoError(t, err)
+}
+
+func TestGetListEventsPage(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+	expected := &pb.GetListEventsResponse{
+		Events:        []*pb.Event{{ID: newUUID, Header: "header", EventTime: timestamppb.New(testTime)}},
+		NextPageToken: "next",
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().GetListEventsPage(ctx, testTime, 30, 1, "").Return(app.Page{
+		Events:        []models.Event{{ID: newUUID, Header: "header", EventTime: testTime}},
+		NextPageToken: "next",
+	}, nil)
+	service.EXPECT().GetListEventsPage(ctx, testTime, 30, 0, "invalid").
+		Return(app.Page{}, fmt.Errorf("%w: invalid page token", app.ErrInvalidArgument))
+	server := NewServer(service, logg)
+
+	response, err := server.GetListEvents(ctx, &pb.GetListEventsRequest{
+		Start:      timestamppb.New(testTime),
+		AmountDays: 30,
+		Limit:      1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, response)
+
+	_, err = server.GetListEvents(ctx, &pb.GetListEventsRequest{
+		Start:      timestamppb.New(testTime),
+		AmountDays: 30,
+		PageToken:  "invalid",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSearchEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+	expected := &pb.GetListEventsResponse{
+		Events: []*pb.Event{{ID: newUUID, Header: "sprint", EventTime: timestamppb.New(testTime)}},
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().SearchEvents(ctx, models.SearchQuery{Text: "sprint", From: testTime, Limit: 5}).
+		Return([]models.Event{{ID: newUUID, Header: "sprint", EventTime: testTime}}, nil)
+	service.EXPECT().SearchEvents(ctx, models.SearchQuery{Text: ""}).
+		Return(nil, fmt.Errorf("%w: search query must contain words", app.ErrInvalidArgument))
+	server := NewServer(service, logg)
+
+	response, err := server.SearchEvents(ctx, &pb.SearchEventsRequest{
+		Query: "sprint",
+		From:  timestamppb.New(testTime),
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, response)
+
+	_, err = server.SearchEvents(ctx, &pb.SearchEventsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPatchEvent(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().PatchEvent(ctx, newUUID, models.EventPatch{
+		Event:  models.Event{ID: newUUID, EventTime: testTime},
+		Fields: []string{"eventTime", "finishEventTime"},
+	}).Return(models.Event{ID: newUUID, Header: "header", EventTime: testTime}, nil)
+	service.EXPECT().PatchEvent(ctx, newUUID, gomock.Any()).Return(models.Event{}, app.ErrEventForbidden)
+	server := NewServer(service, logg)
+
+	response, err := server.PatchEvent(ctx, &pb.PatchEventRequest{
+		Event:      &pb.Event{ID: newUUID, EventTime: timestamppb.New(testTime)},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"EventTime", "finish_event_time"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &pb.Event{ID: newUUID, Header: "header", EventTime: timestamppb.New(testTime)}, response)
+
+	_, err = server.PatchEvent(ctx, &pb.PatchEventRequest{
+		Event:      &pb.Event{ID: newUUID, Header: "header"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"Header"}},
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = server.PatchEvent(ctx, &pb.PatchEventRequest{Event: &pb.Event{ID: newUUID}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDeleteEventVersion(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	newUUID := uuid.New().String()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().DeleteEvent(ctx, newUUID, int64(3)).Return(app.ErrVersionMismatch)
+	server := NewServer(service, logg)
+
+	_, err = server.DeleteEvent(ctx, &pb.DeleteEventRequest{Id: newUUID, ExpectedVersion: 3})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestTrash(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	deletedAt := testTime.Add(time.Hour)
+	newUUID := uuid.New().String()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().GetTrash(ctx).
+		Return([]models.Event{{ID: newUUID, EventTime: testTime, DeletedAt: &deletedAt}}, nil)
+	service.EXPECT().RestoreEvent(ctx, newUUID).Return(models.Event{ID: newUUID, EventTime: testTime, Version: 3}, nil)
+	service.EXPECT().RestoreEvent(ctx, newUUID).Return(models.Event{}, app.ErrEventNotDeleted)
+	server := NewServer(service, logg)
+
+	trash, err := server.GetTrash(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, &pb.GetListEventsResponse{Events: []*pb.Event{{
+		ID: newUUID, EventTime: timestamppb.New(testTime), DeletedAt: timestamppb.New(deletedAt),
+	}}}, trash)
+
+	restored, err := server.RestoreEvent(ctx, &pb.RestoreEventRequest{Id: newUUID})
+	require.NoError(t, err)
+	require.Equal(t, &pb.Event{ID: newUUID, EventTime: timestamppb.New(testTime), Version: 3}, restored)
+
+	_, err = server.RestoreEvent(ctx, &pb.RestoreEventRequest{Id: newUUID})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestGetEventHistory(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+	before := models.Event{ID: newUUID, Header: "test1", EventTime: testTime, Version: 1}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().GetEventHistory(ctx, newUUID).Return([]models.AuditEntry{{
+		ID: 2, EventID: newUUID, UserID: "user", Actor: "admin", Action: models.AuditDelete, Before: &before, Time: testTime,
+	}}, nil)
+	service.EXPECT().GetEventHistory(ctx, newUUID).Return(nil, app.ErrEventNotFound)
+	server := NewServer(service, logg)
+
+	history, err := server.GetEventHistory(ctx, &pb.GetEventRequest{Id: newUUID})
+	require.NoError(t, err)
+	require.Equal(t, &pb.GetEventHistoryResponse{Entries: []*pb.AuditEntry{{
+		Id: 2, EventID: newUUID, UserID: "user", Actor: "admin", Action: "delete",
+		Before: &pb.Event{ID: newUUID, Header: "test1", EventTime: timestamppb.New(testTime), Version: 1},
+		Time:   timestamppb.New(testTime),
+	}}}, history)
+
+	_, err = server.GetEventHistory(ctx, &pb.GetEventRequest{Id: newUUID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// batchStream replays the requests of a client stream and keeps the response.
+type batchStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*pb.BatchEventsRequest
+	response *pb.BatchEventsResponse
+}
+
+func (s *batchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *batchStream) Recv() (*pb.BatchEventsRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+
+	return req, nil
+}
+
+func (s *batchStream) SendAndClose(response *pb.BatchEventsResponse) error {
+	s.response = response
+	return nil
+}
+
+func TestBatchEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	service.EXPECT().ApplyBatch(ctx, []models.BatchItem{
+		{Operation: models.BatchCreate, Event: models.Event{Header: "test1", EventTime: testTime}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: newUUID, Version: 2}},
+		{Operation: models.BatchDelete, Event: models.Event{}},
+	}, true).Return([]models.BatchResult{
+		{Err: app.ErrBatchAborted},
+		{ID: newUUID, Err: app.ErrVersionMismatch},
+		{Err: app.ErrBatchAborted},
+	}, nil)
+	server := NewServer(service, logg)
+
+	stream := &batchStream{ctx: ctx, requests: []*pb.BatchEventsRequest{
+		{Items: []*pb.BatchItem{{
+			Operation: "create", Event: &pb.Event{Header: "test1", EventTime: timestamppb.New(testTime)},
+		}}},
+		{Items: []*pb.BatchItem{{Operation: "delete", Event: &pb.Event{ID: newUUID, Version: 2}}}, Atomic: true},
+		{Items: []*pb.BatchItem{{Operation: "delete"}}},
+	}}
+	require.NoError(t, server.BatchEvents(stream))
+	require.Equal(t, &pb.BatchEventsResponse{Results: []*pb.BatchResult{
+		{Code: int32(codes.Aborted), Error: app.ErrBatchAborted.Error()},
+		{Id: newUUID, Code: int32(codes.FailedPrecondition), Error: app.ErrVersionMismatch.Error()},
+		{Code: int32(codes.Aborted), Error: app.ErrBatchAborted.Error()},
+	}}, stream.response)
+
+	invalid := &batchStream{ctx: ctx, requests: []*pb.BatchEventsRequest{
+		{Items: []*pb.BatchItem{{
+			Operation: "create", Event: &pb.Event{EventTime: &timestamppb.Timestamp{Seconds: -1 << 62}},
+		}}},
+	}}
+	require.Equal(t, codes.InvalidArgument, status.Code(server.BatchEvents(invalid)))
+}
+
+// watchStream keeps the changes sent to the client.
+type watchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	changes []*pb.EventChange
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(change *pb.EventChange) error {
+	s.changes = append(s.changes, change)
+	return nil
+}
+
+func TestWatchEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	newUUID := uuid.New().String()
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	changes := make(chan models.Change, 2)
+	changes <- models.Change{
+		Revision: 7, Type: models.ChangeCreated, Event: models.Event{ID: newUUID, EventTime: testTime}, Time: testTime,
+	}
+	changes <- models.Change{Revision: 9, Type: models.ChangeDeleted, Event: models.Event{ID: newUUID}, Time: testTime}
+	close(changes)
+	service.EXPECT().WatchEvents(gomock.Any(), int64(5)).Return(changes, nil)
+	closed := make(chan models.Change)
+	close(closed)
+	service.EXPECT().WatchEvents(canceledCtx, int64(0)).Return(closed, nil)
+	service.EXPECT().WatchEvents(gomock.Any(), int64(-1)).Return(nil, app.ErrInvalidArgument)
+	server := NewServer(service, logg)
+
+	stream := &watchStream{ctx: context.Background()}
+	err = server.WatchEvents(&pb.WatchEventsRequest{AfterRevision: 5}, stream)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, []*pb.EventChange{
+		{
+			Revision: 7, Type: "created", Time: timestamppb.New(testTime),
+			Event: &pb.Event{ID: newUUID, EventTime: timestamppb.New(testTime)},
+		},
+		{
+			Revision: 9, Type: "deleted", Time: timestamppb.New(testTime),
+			Event: &pb.Event{ID: newUUID, EventTime: timestamppb.New(time.Time{})},
+		},
+	}, stream.changes)
+
+	err = server.WatchEvents(&pb.WatchEventsRequest{}, &watchStream{ctx: canceledCtx})
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	err = server.WatchEvents(&pb.WatchEventsRequest{AfterRevision: -1}, &watchStream{ctx: context.Background()})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestValidationDetails(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+	service := mockservice.NewMockApplicationInterface(c)
+	validationErr := validator.ValidationErrors{
+		{Field: "header", Err: fmt.Errorf("%w 255", validator.ErrMoreThanMax)},
+		{Field: "notificationTime", Err: fmt.Errorf("%w eventTime", validator.ErrNotBefore)},
+	}
+	service.EXPECT().CreateEvent(ctx, gomock.Any()).
+		Return("", fmt.Errorf("%w: %w", app.ErrInvalidArgument, validationErr))
+	server := NewServer(service, logg)
+
+	_, err = server.CreateEvent(ctx, &pb.Event{Header: "header"})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.FieldViolations, 2)
+	require.Equal(t, "header", badRequest.FieldViolations[0].Field)
+	require.Equal(t, "value is more than maximum 255", badRequest.FieldViolations[0].Description)
+	require.Equal(t, "notificationTime", badRequest.FieldViolations[1].Field)
+	require.Equal(t, "value is not before eventTime", badRequest.FieldViolations[1].Description)
+}
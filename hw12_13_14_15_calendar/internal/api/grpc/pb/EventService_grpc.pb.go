@@ -9,6 +9,7 @@ package pb
 import (
 	context "context"
 	empty "github.com/golang/protobuf/ptypes/empty"
+	httpbody "google.golang.org/genproto/googleapis/api/httpbody"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -20,10 +21,22 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	EventService_CreateEvent_FullMethodName   = "/event.EventService/CreateEvent"
-	EventService_UpdateEvent_FullMethodName   = "/event.EventService/UpdateEvent"
-	EventService_DeleteEvent_FullMethodName   = "/event.EventService/DeleteEvent"
-	EventService_GetListEvents_FullMethodName = "/event.EventService/GetListEvents"
+	EventService_CreateEvent_FullMethodName          = "/event.EventService/CreateEvent"
+	EventService_UpdateEvent_FullMethodName          = "/event.EventService/UpdateEvent"
+	EventService_PatchEvent_FullMethodName           = "/event.EventService/PatchEvent"
+	EventService_DeleteEvent_FullMethodName          = "/event.EventService/DeleteEvent"
+	EventService_BatchEvents_FullMethodName          = "/event.EventService/BatchEvents"
+	EventService_GetEvent_FullMethodName             = "/event.EventService/GetEvent"
+	EventService_GetTrash_FullMethodName             = "/event.EventService/GetTrash"
+	EventService_RestoreEvent_FullMethodName         = "/event.EventService/RestoreEvent"
+	EventService_GetEventHistory_FullMethodName      = "/event.EventService/GetEventHistory"
+	EventService_WatchEvents_FullMethodName          = "/event.EventService/WatchEvents"
+	EventService_GetListEvents_FullMethodName        = "/event.EventService/GetListEvents"
+	EventService_GetListEventsByWeek_FullMethodName  = "/event.EventService/GetListEventsByWeek"
+	EventService_GetListEventsByMonth_FullMethodName = "/event.EventService/GetListEventsByMonth"
+	EventService_SearchEvents_FullMethodName         = "/event.EventService/SearchEvents"
+	EventService_ExportEvents_FullMethodName         = "/event.EventService/ExportEvents"
+	EventService_ImportEvents_FullMethodName         = "/event.EventService/ImportEvents"
 )
 
 // EventServiceClient is the client API for EventService service.
@@ -32,8 +45,22 @@ const (
 type EventServiceClient interface {
 	CreateEvent(ctx context.Context, in *Event, opts ...grpc.CallOption) (*CreateEventResponse, error)
 	UpdateEvent(ctx context.Context, in *Event, opts ...grpc.CallOption) (*empty.Empty, error)
+	PatchEvent(ctx context.Context, in *PatchEventRequest, opts ...grpc.CallOption) (*Event, error)
 	DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	BatchEvents(ctx context.Context, opts ...grpc.CallOption) (EventService_BatchEventsClient, error)
+	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error)
+	GetTrash(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetListEventsResponse, error)
+	RestoreEvent(ctx context.Context, in *RestoreEventRequest, opts ...grpc.CallOption) (*Event, error)
+	GetEventHistory(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*GetEventHistoryResponse, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (EventService_WatchEventsClient, error)
 	GetListEvents(ctx context.Context, in *GetListEventsRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error)
+	GetListEventsByWeek(ctx context.Context, in *GetListEventsByPeriodRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error)
+	GetListEventsByMonth(ctx context.Context, in *GetListEventsByPeriodRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error)
+	SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error)
+	// ExportEvents returns the events as an iCalendar file, over REST it is the body of the response.
+	ExportEvents(ctx context.Context, in *GetListEventsRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	// ImportEvents creates the events of an iCalendar file, over REST it is the text/calendar body of the request.
+	ImportEvents(ctx context.Context, in *ImportEventsRequest, opts ...grpc.CallOption) (*ImportEventsResponse, error)
 }
 
 type eventServiceClient struct {
@@ -62,6 +89,15 @@ func (c *eventServiceClient) UpdateEvent(ctx context.Context, in *Event, opts ..
 	return out, nil
 }
 
+func (c *eventServiceClient) PatchEvent(ctx context.Context, in *PatchEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_PatchEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *eventServiceClient) DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
 	err := c.cc.Invoke(ctx, EventService_DeleteEvent_FullMethodName, in, out, opts...)
@@ -71,6 +107,108 @@ func (c *eventServiceClient) DeleteEvent(ctx context.Context, in *DeleteEventReq
 	return out, nil
 }
 
+func (c *eventServiceClient) BatchEvents(ctx context.Context, opts ...grpc.CallOption) (EventService_BatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[0], EventService_BatchEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventServiceBatchEventsClient{stream}
+	return x, nil
+}
+
+type EventService_BatchEventsClient interface {
+	Send(*BatchEventsRequest) error
+	CloseAndRecv() (*BatchEventsResponse, error)
+	grpc.ClientStream
+}
+
+type eventServiceBatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventServiceBatchEventsClient) Send(m *BatchEventsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *eventServiceBatchEventsClient) CloseAndRecv() (*BatchEventsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BatchEventsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *eventServiceClient) GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_GetEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetTrash(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*GetListEventsResponse, error) {
+	out := new(GetListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_GetTrash_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) RestoreEvent(ctx context.Context, in *RestoreEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_RestoreEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetEventHistory(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*GetEventHistoryResponse, error) {
+	out := new(GetEventHistoryResponse)
+	err := c.cc.Invoke(ctx, EventService_GetEventHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (EventService_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[1], EventService_WatchEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventServiceWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventService_WatchEventsClient interface {
+	Recv() (*EventChange, error)
+	grpc.ClientStream
+}
+
+type eventServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventServiceWatchEventsClient) Recv() (*EventChange, error) {
+	m := new(EventChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *eventServiceClient) GetListEvents(ctx context.Context, in *GetListEventsRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error) {
 	out := new(GetListEventsResponse)
 	err := c.cc.Invoke(ctx, EventService_GetListEvents_FullMethodName, in, out, opts...)
@@ -80,14 +218,73 @@ func (c *eventServiceClient) GetListEvents(ctx context.Context, in *GetListEvent
 	return out, nil
 }
 
+func (c *eventServiceClient) GetListEventsByWeek(ctx context.Context, in *GetListEventsByPeriodRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error) {
+	out := new(GetListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_GetListEventsByWeek_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetListEventsByMonth(ctx context.Context, in *GetListEventsByPeriodRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error) {
+	out := new(GetListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_GetListEventsByMonth_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*GetListEventsResponse, error) {
+	out := new(GetListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_SearchEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ExportEvents(ctx context.Context, in *GetListEventsRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	err := c.cc.Invoke(ctx, EventService_ExportEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ImportEvents(ctx context.Context, in *ImportEventsRequest, opts ...grpc.CallOption) (*ImportEventsResponse, error) {
+	out := new(ImportEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_ImportEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventServiceServer is the server API for EventService service.
 // All implementations must embed UnimplementedEventServiceServer
 // for forward compatibility
 type EventServiceServer interface {
 	CreateEvent(context.Context, *Event) (*CreateEventResponse, error)
 	UpdateEvent(context.Context, *Event) (*empty.Empty, error)
+	PatchEvent(context.Context, *PatchEventRequest) (*Event, error)
 	DeleteEvent(context.Context, *DeleteEventRequest) (*empty.Empty, error)
+	BatchEvents(EventService_BatchEventsServer) error
+	GetEvent(context.Context, *GetEventRequest) (*Event, error)
+	GetTrash(context.Context, *empty.Empty) (*GetListEventsResponse, error)
+	RestoreEvent(context.Context, *RestoreEventRequest) (*Event, error)
+	GetEventHistory(context.Context, *GetEventRequest) (*GetEventHistoryResponse, error)
+	WatchEvents(*WatchEventsRequest, EventService_WatchEventsServer) error
 	GetListEvents(context.Context, *GetListEventsRequest) (*GetListEventsResponse, error)
+	GetListEventsByWeek(context.Context, *GetListEventsByPeriodRequest) (*GetListEventsResponse, error)
+	GetListEventsByMonth(context.Context, *GetListEventsByPeriodRequest) (*GetListEventsResponse, error)
+	SearchEvents(context.Context, *SearchEventsRequest) (*GetListEventsResponse, error)
+	// ExportEvents returns the events as an iCalendar file, over REST it is the body of the response.
+	ExportEvents(context.Context, *GetListEventsRequest) (*httpbody.HttpBody, error)
+	// ImportEvents creates the events of an iCalendar file, over REST it is the text/calendar body of the request.
+	ImportEvents(context.Context, *ImportEventsRequest) (*ImportEventsResponse, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
 
@@ -101,12 +298,48 @@ func (UnimplementedEventServiceServer) CreateEvent(context.Context, *Event) (*Cr
 func (UnimplementedEventServiceServer) UpdateEvent(context.Context, *Event) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateEvent not implemented")
 }
+func (UnimplementedEventServiceServer) PatchEvent(context.Context, *PatchEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PatchEvent not implemented")
+}
 func (UnimplementedEventServiceServer) DeleteEvent(context.Context, *DeleteEventRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEvent not implemented")
 }
+func (UnimplementedEventServiceServer) BatchEvents(EventService_BatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method BatchEvents not implemented")
+}
+func (UnimplementedEventServiceServer) GetEvent(context.Context, *GetEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
+}
+func (UnimplementedEventServiceServer) GetTrash(context.Context, *empty.Empty) (*GetListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrash not implemented")
+}
+func (UnimplementedEventServiceServer) RestoreEvent(context.Context, *RestoreEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreEvent not implemented")
+}
+func (UnimplementedEventServiceServer) GetEventHistory(context.Context, *GetEventRequest) (*GetEventHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventHistory not implemented")
+}
+func (UnimplementedEventServiceServer) WatchEvents(*WatchEventsRequest, EventService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
 func (UnimplementedEventServiceServer) GetListEvents(context.Context, *GetListEventsRequest) (*GetListEventsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetListEvents not implemented")
 }
+func (UnimplementedEventServiceServer) GetListEventsByWeek(context.Context, *GetListEventsByPeriodRequest) (*GetListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetListEventsByWeek not implemented")
+}
+func (UnimplementedEventServiceServer) GetListEventsByMonth(context.Context, *GetListEventsByPeriodRequest) (*GetListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetListEventsByMonth not implemented")
+}
+func (UnimplementedEventServiceServer) SearchEvents(context.Context, *SearchEventsRequest) (*GetListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchEvents not implemented")
+}
+func (UnimplementedEventServiceServer) ExportEvents(context.Context, *GetListEventsRequest) (*httpbody.HttpBody, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportEvents not implemented")
+}
+func (UnimplementedEventServiceServer) ImportEvents(context.Context, *ImportEventsRequest) (*ImportEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportEvents not implemented")
+}
 func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
 
 // UnsafeEventServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -156,6 +389,24 @@ func _EventService_UpdateEvent_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_PatchEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PatchEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).PatchEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_PatchEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).PatchEvent(ctx, req.(*PatchEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EventService_DeleteEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteEventRequest)
 	if err := dec(in); err != nil {
@@ -174,6 +425,125 @@ func _EventService_DeleteEvent_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_BatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventServiceServer).BatchEvents(&eventServiceBatchEventsServer{stream})
+}
+
+type EventService_BatchEventsServer interface {
+	SendAndClose(*BatchEventsResponse) error
+	Recv() (*BatchEventsRequest, error)
+	grpc.ServerStream
+}
+
+type eventServiceBatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventServiceBatchEventsServer) SendAndClose(m *BatchEventsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *eventServiceBatchEventsServer) Recv() (*BatchEventsRequest, error) {
+	m := new(BatchEventsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _EventService_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetEvent(ctx, req.(*GetEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetTrash(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_RestoreEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).RestoreEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_RestoreEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).RestoreEvent(ctx, req.(*RestoreEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetEventHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetEventHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetEventHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetEventHistory(ctx, req.(*GetEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventServiceServer).WatchEvents(m, &eventServiceWatchEventsServer{stream})
+}
+
+type EventService_WatchEventsServer interface {
+	Send(*EventChange) error
+	grpc.ServerStream
+}
+
+type eventServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventServiceWatchEventsServer) Send(m *EventChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _EventService_GetListEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetListEventsRequest)
 	if err := dec(in); err != nil {
@@ -192,6 +562,96 @@ func _EventService_GetListEvents_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_GetListEventsByWeek_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetListEventsByPeriodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetListEventsByWeek(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetListEventsByWeek_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetListEventsByWeek(ctx, req.(*GetListEventsByPeriodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetListEventsByMonth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetListEventsByPeriodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetListEventsByMonth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetListEventsByMonth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetListEventsByMonth(ctx, req.(*GetListEventsByPeriodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_SearchEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).SearchEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_SearchEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).SearchEvents(ctx, req.(*SearchEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ExportEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetListEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ExportEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ExportEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ExportEvents(ctx, req.(*GetListEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ImportEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ImportEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ImportEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ImportEvents(ctx, req.(*ImportEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -207,15 +667,66 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateEvent",
 			Handler:    _EventService_UpdateEvent_Handler,
 		},
+		{
+			MethodName: "PatchEvent",
+			Handler:    _EventService_PatchEvent_Handler,
+		},
 		{
 			MethodName: "DeleteEvent",
 			Handler:    _EventService_DeleteEvent_Handler,
 		},
+		{
+			MethodName: "GetEvent",
+			Handler:    _EventService_GetEvent_Handler,
+		},
+		{
+			MethodName: "GetTrash",
+			Handler:    _EventService_GetTrash_Handler,
+		},
+		{
+			MethodName: "RestoreEvent",
+			Handler:    _EventService_RestoreEvent_Handler,
+		},
+		{
+			MethodName: "GetEventHistory",
+			Handler:    _EventService_GetEventHistory_Handler,
+		},
 		{
 			MethodName: "GetListEvents",
 			Handler:    _EventService_GetListEvents_Handler,
 		},
+		{
+			MethodName: "GetListEventsByWeek",
+			Handler:    _EventService_GetListEventsByWeek_Handler,
+		},
+		{
+			MethodName: "GetListEventsByMonth",
+			Handler:    _EventService_GetListEventsByMonth_Handler,
+		},
+		{
+			MethodName: "SearchEvents",
+			Handler:    _EventService_SearchEvents_Handler,
+		},
+		{
+			MethodName: "ExportEvents",
+			Handler:    _EventService_ExportEvents_Handler,
+		},
+		{
+			MethodName: "ImportEvents",
+			Handler:    _EventService_ImportEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchEvents",
+			Handler:       _EventService_BatchEvents_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _EventService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "EventService.proto",
 }
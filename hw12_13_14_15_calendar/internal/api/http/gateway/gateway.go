@@ -0,0 +1,133 @@
+// Package gateway serves EventService as REST by the HTTP rules of api/EventService.proto.
+package gateway
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/grpcserver"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/grpc/pb"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/http/handlers"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// calendarContentType is the media type of iCalendar bodies, runtime picks marshalers by it without parameters.
+const calendarContentType = "text/calendar"
+
+// defaultMarshaler is the marshaler runtime uses when none is registered for the content type.
+var defaultMarshaler = &runtime.HTTPBodyMarshaler{
+	Marshaler: &runtime.JSONPb{
+		MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	},
+}
+
+// NewHandler calls the gRPC server in process, so REST requests get the same conversion and error codes as gRPC
+// ones, errors are written as problem details.
+// In process calls do not stream, so BatchEvents and WatchEvents are left to the routes of handlers.InitRoutes.
+func NewHandler(ctx context.Context, logger app.Logger, service api.ApplicationInterface) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithErrorHandler(writeProblem),
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+		runtime.WithMarshalerOption(calendarContentType, calendarMarshaler{Marshaler: defaultMarshaler}),
+	)
+	if err := pb.RegisterEventServiceHandlerServer(ctx, mux, grpcserver.NewServer(service, logger)); err != nil {
+		return nil, fmt.Errorf("error while registering event service gateway: %w", err)
+	}
+
+	return identity(mux), nil
+}
+
+// identity does the work of grpcserver.UserIDInterceptor, in process calls skip interceptors.
+func identity(mux *runtime.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Header.Get(handlers.UserIDHeader)
+		if userID == "" {
+			_, marshaler := runtime.MarshalerForRequest(mux, r)
+			err := status.Errorf(codes.Unauthenticated, "%s header is required", handlers.UserIDHeader)
+			runtime.HTTPError(r.Context(), mux, marshaler, w, r, err)
+			return
+		}
+
+		mux.ServeHTTP(w, r.WithContext(app.ContextWithUserID(r.Context(), userID)))
+	})
+}
+
+// writeProblem writes errors as problem details like the routes of handlers.InitRoutes do,
+// field violations of BadRequest details are listed as invalid params.
+func writeProblem(
+	_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error,
+) {
+	st := status.Convert(err)
+	var invalidParams []handlers.InvalidParam
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				invalidParams = append(invalidParams,
+					handlers.InvalidParam{Name: violation.GetField(), Reason: violation.GetDescription()})
+			}
+		}
+	}
+
+	handlers.WriteProblem(w, httpStatusFromCode(st.Code()), st.Message(), invalidParams...)
+}
+
+// httpStatusFromCode is runtime.HTTPStatusFromCode but for FailedPrecondition, which is the code of versions
+// not matching the expected one, conditional requests fail with 412 then.
+func httpStatusFromCode(code codes.Code) int {
+	if code == codes.FailedPrecondition {
+		return http.StatusPreconditionFailed
+	}
+
+	return runtime.HTTPStatusFromCode(code)
+}
+
+// incomingHeader passes If-Match to the server as the expected version of the event.
+func incomingHeader(key string) (string, bool) {
+	if key == "If-Match" {
+		return grpcserver.IfMatchMetadataKey, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeader returns the version of the event as ETag, other metadata keeps the prefix of the gateway.
+func outgoingHeader(key string) (string, bool) {
+	if key == grpcserver.ETagMetadataKey {
+		return "ETag", true
+	}
+
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
+
+// calendarMarshaler takes an iCalendar body of a request as it is, the rest is done by the wrapped marshaler.
+type calendarMarshaler struct {
+	runtime.Marshaler
+}
+
+func (m calendarMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v interface{}) error {
+		calendar, ok := v.(*[]byte)
+		if !ok {
+			return m.Marshaler.NewDecoder(r).Decode(v)
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("error while reading calendar: %w", err)
+		}
+		*calendar = data
+
+		return nil
+	})
+}
@@ -0,0 +1,343 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/http/handlers"
+	mockservice "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/mocks"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/ical"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		contentType        string
+		userID             string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:   "create event",
+			method: http.MethodPost,
+			path:   "/v1/events",
+			body:   `{"Header":"test1","EventTime":"2024-02-14T10:00:00Z"}`,
+			userID: testUserID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().CreateEvent(gomock.Any(), models.Event{Header: "test1", EventTime: testTime}).
+					DoAndReturn(func(ctx context.Context, _ models.Event) (string, error) {
+						userID, _ := app.UserIDFromContext(ctx)
+						require.Equal(t, testUserID, userID)
+
+						return testEventID, nil
+					})
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       fmt.Sprintf(`{"id":%q}`, testEventID),
+		},
+		{
+			name:        "patch event by merge patch",
+			method:      http.MethodPatch,
+			path:        "/v1/events/" + testEventID,
+			body:        `{"Header":"test2","FinishEventTime":null}`,
+			contentType: "application/merge-patch+json",
+			userID:      testUserID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, patch models.EventPatch) (models.Event, error) {
+						require.Equal(t, models.Event{ID: testEventID, Header: "test2"}, patch.Event)
+						require.ElementsMatch(t, []string{"header", "finishEventTime"}, patch.Fields)
+
+						return models.Event{ID: testEventID, Header: "test2", EventTime: testTime, Version: 2}, nil
+					})
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: fmt.Sprintf(`{"ID":%q,"Header":"test2","Description":"","UserID":"",`+
+				`"EventTime":"2024-02-14T10:00:00Z","FinishEventTime":null,"NotificationTime":null,"Recurrence":"",`+
+				`"ExDates":[],"TimeZone":"","Version":"2","DeletedAt":null,"RemindBefore":null}`, testEventID),
+		},
+		{
+			name:   "patch event by update mask of the query",
+			method: http.MethodPatch,
+			path:   "/v1/events/" + testEventID + "?updateMask=header",
+			body:   `{"Header":"test2","Description":"ignored","Version":"1"}`,
+			userID: testUserID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, models.EventPatch{
+					Event:  models.Event{ID: testEventID, Header: "test2", Description: "ignored", Version: 1},
+					Fields: []string{"header"},
+				}).Return(models.Event{ID: testEventID, Header: "test2", EventTime: testTime, Version: 2}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: fmt.Sprintf(`{"ID":%q,"Header":"test2","Description":"","UserID":"",`+
+				`"EventTime":"2024-02-14T10:00:00Z","FinishEventTime":null,"NotificationTime":null,"Recurrence":"",`+
+				`"ExDates":[],"TimeZone":"","Version":"2","DeletedAt":null,"RemindBefore":null}`, testEventID),
+		},
+		{
+			name:   "list events by query",
+			method: http.MethodGet,
+			path:   "/v1/events?start=2024-02-14T10:00:00Z&amountDays=2",
+			userID: testUserID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsDuringFewDays(gomock.Any(), testTime, 2).Return(nil, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"events":[],"nextPageToken":""}`,
+		},
+		{
+			name:   "not found event",
+			method: http.MethodGet,
+			path:   "/v1/events/" + testEventID,
+			userID: testUserID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetEvent(gomock.Any(), testEventID).
+					Return(models.Event{}, fmt.Errorf("%w: event %s", app.ErrNotFound, testEventID))
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody: fmt.Sprintf(`{"type":"about:blank","title":"Not Found","status":404,`+
+				`"detail":"not found: event %s"}`, testEventID),
+		},
+		{
+			name:   "invalid event",
+			method: http.MethodPost,
+			path:   "/v1/events",
+			body:   `{"Header":"","EventTime":"2024-02-14T10:00:00Z"}`,
+			userID: testUserID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().CreateEvent(gomock.Any(), models.Event{EventTime: testTime}).
+					Return("", fmt.Errorf("%w: %w", app.ErrInvalidArgument, validator.ValidationErrors{
+						{Field: "header", Err: errors.New("is required")},
+					}))
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody: `{"type":"about:blank","title":"Bad Request","status":400,` +
+				`"detail":"invalid argument: header: is required",` +
+				`"invalid-params":[{"name":"header","reason":"is required"}]}`,
+		},
+		{
+			name:               "without user id",
+			method:             http.MethodGet,
+			path:               "/v1/events/" + testEventID,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody: `{"type":"about:blank","title":"Unauthorized","status":401,` +
+				`"detail":"X-User-ID header is required"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler, err := NewHandler(context.Background(), logg, appInterface)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.path, strings.NewReader(testCase.body))
+			if testCase.userID != "" {
+				req.Header.Set(handlers.UserIDHeader, testCase.userID)
+			}
+			if testCase.contentType != "" {
+				req.Header.Set("Content-Type", testCase.contentType)
+			}
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, testCase.expectedStatusCode, w.Code)
+			require.JSONEq(t, testCase.expectedBody, w.Body.String())
+			if w.Code >= http.StatusBadRequest {
+				require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGatewayVersions(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		method             string
+		body               string
+		ifMatch            string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedETag       string
+	}{
+		{
+			name:   "get returns ETag",
+			method: http.MethodGet,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetEvent(gomock.Any(), testEventID).
+					Return(models.Event{ID: testEventID, EventTime: testTime, Version: 7}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"7"`,
+		},
+		{
+			name:    "update with If-Match",
+			method:  http.MethodPut,
+			body:    `{"Header":"new"}`,
+			ifMatch: `"7"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().UpdateEvent(gomock.Any(), models.Event{ID: testEventID, Header: "new", Version: 7}).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"8"`,
+		},
+		{
+			name:    "patch returns new ETag",
+			method:  http.MethodPatch,
+			body:    `{"Header":"new"}`,
+			ifMatch: `"7"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, models.EventPatch{
+					Event:  models.Event{ID: testEventID, Header: "new", Version: 7},
+					Fields: []string{"header"},
+				}).Return(models.Event{ID: testEventID, Header: "new", EventTime: testTime, Version: 8}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"8"`,
+		},
+		{
+			name:    "patch with stale If-Match",
+			method:  http.MethodPatch,
+			body:    `{"Header":"new"}`,
+			ifMatch: `"6"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, models.EventPatch{
+					Event:  models.Event{ID: testEventID, Header: "new", Version: 6},
+					Fields: []string{"header"},
+				}).Return(models.Event{}, app.ErrVersionMismatch)
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "delete with stale If-Match",
+			method:  http.MethodDelete,
+			ifMatch: `"6"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().DeleteEvent(gomock.Any(), testEventID, int64(6)).Return(app.ErrVersionMismatch)
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "delete of any version",
+			method:  http.MethodDelete,
+			ifMatch: "*",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().DeleteEvent(gomock.Any(), testEventID, int64(0)).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "weak entity tag",
+			method:             http.MethodDelete,
+			ifMatch:            `W/"7"`,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler, err := NewHandler(context.Background(), logg, appInterface)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/v1/events/"+testEventID, strings.NewReader(testCase.body))
+			req.Header.Set(handlers.UserIDHeader, uuid.New().String())
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, testCase.expectedStatusCode, w.Code, w.Body.String())
+			require.Equal(t, testCase.expectedETag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+// TestGatewayCalendarRoundTrip imports a raw iCalendar file and exports it back with the real application.
+func TestGatewayCalendarRoundTrip(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	calendar := app.New(logg, memorystorage.New(logg), time.Monday)
+	defer calendar.Close()
+	handler, err := NewHandler(context.Background(), logg, calendar)
+	require.NoError(t, err)
+	userID := uuid.New().String()
+	file := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:first@test\r\nDTSTART:20240214T100000Z\r\nDTEND:20240214T110000Z\r\n" +
+		"SUMMARY:Planning\r\nDESCRIPTION:Sprint\\, goals\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:second@test\r\nDTSTART:20240215T090000Z\r\nSUMMARY:Review\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/events:import", strings.NewReader(file))
+	req.Header.Set("Content-Type", ical.ContentType)
+	req.Header.Set(handlers.UserIDHeader, userID)
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var imported struct {
+		IDs []string `json:"ids"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &imported))
+	require.Len(t, imported.IDs, 2)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/v1/events:export?start=2024-02-14T00:00:00Z&amountDays=7", nil)
+	req.Header.Set(handlers.UserIDHeader, userID)
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, ical.ContentType, w.Header().Get("Content-Type"))
+
+	events, err := ical.Decode(strings.NewReader(file))
+	require.NoError(t, err)
+	exported, err := ical.Decode(w.Body)
+	require.NoError(t, err)
+	require.Len(t, exported, len(events))
+	sort.Slice(exported, func(i, j int) bool { return exported[i].EventTime.Before(exported[j].EventTime) })
+	for i := range events {
+		require.Equal(t, events[i].Header, exported[i].Header)
+		require.Equal(t, events[i].Description, exported[i].Description)
+		require.True(t, events[i].EventTime.Equal(exported[i].EventTime), "event time %v", exported[i].EventTime)
+		require.Equal(t, events[i].FinishEventTime == nil, exported[i].FinishEventTime == nil)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+//nolint:depguard
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem is an error response body as described in RFC 7807.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// InvalidParams lists the fields which failed validation.
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam is a field which failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+func newProblem(status int, detail string) problem {
+	return problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	newProblem(status, detail).write(w)
+}
+
+// WriteProblem writes the error as problem details, so errors of other handlers look like those of the routes.
+func WriteProblem(w http.ResponseWriter, status int, detail string, invalidParams ...InvalidParam) {
+	p := newProblem(status, detail)
+	p.InvalidParams = invalidParams
+	p.write(w)
+}
+
+func (p problem) write(w http.ResponseWriter) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	p := newProblem(statusFromError(err), err.Error())
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, validationErr := range validationErrors {
+			p.InvalidParams = append(p.InvalidParams, InvalidParam{
+				Name:   validationErr.Field,
+				Reason: validationErr.Err.Error(),
+			})
+		}
+	}
+
+	p.write(w)
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, app.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, app.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, app.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, app.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, app.ErrAborted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
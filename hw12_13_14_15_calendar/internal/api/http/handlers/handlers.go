@@ -4,21 +4,34 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 )
 
+const (
+	monthLayout         = "2006-01"
+	nextPageTokenHeader = "X-Next-Page-Token"
+)
+
+var isoWeekRegexp = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
 func (h *Handler) helloHandler(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err := w.Write([]byte("Hello"))
 	if err != nil {
 		h.logger.Error("error while writing response", map[string]interface{}{"handler": "helloHandler", "error": err})
-		http.Error(w, fmt.Sprintf("helloHandler: error while writing response:%s", err), 500)
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("helloHandler: error while writing response:%s", err))
 		return
 	}
 }
@@ -28,13 +41,13 @@ func (h *Handler) createEvent(w http.ResponseWriter, req *http.Request) {
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
 		h.logger.Error("Error while decoding request", map[string]interface{}{"error": err})
-		http.Error(w, err.Error(), 400)
+		writeProblem(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	id, err := h.app.CreateEvent(req.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, err)
 		return
 	}
 
@@ -47,7 +60,7 @@ func (h *Handler) updateEvent(w http.ResponseWriter, req *http.Request) {
 	decoder := json.NewDecoder(req.Body)
 	if err := decoder.Decode(&input); err != nil {
 		h.logger.Error("Error while decoding request", map[string]interface{}{"error": err})
-		http.Error(w, err.Error(), 400)
+		writeProblem(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -55,90 +68,642 @@ func (h *Handler) updateEvent(w http.ResponseWriter, req *http.Request) {
 	err := uuid.Validate(id)
 	if err != nil {
 		h.logger.Error("invalid id", map[string]interface{}{"error": err})
-		http.Error(w, err.Error(), 400)
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, ok := h.parseIfMatch(w, req)
+	if !ok {
 		return
 	}
 
 	input.ID = id
+	if version != 0 {
+		input.Version = version
+	}
+
 	err = h.app.UpdateEvent(req.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, err)
 		return
 	}
 
+	// An update makes the next version of the expected one.
+	if input.Version != 0 {
+		setETag(w, input.Version+1)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// patchEvent applies a JSON merge patch (RFC 7396): present members replace fields, null clears them.
+func (h *Handler) patchEvent(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/event/")
+	if err := uuid.Validate(id); err != nil {
+		h.logger.Error("invalid id", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Error("Error while reading request", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		h.logger.Error("Error while decoding request", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, "merge patch must be a JSON object")
+		return
+	}
+
+	var patch models.EventPatch
+	if err := json.Unmarshal(body, &patch.Event); err != nil {
+		h.logger.Error("Error while decoding request", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for field := range members {
+		patch.Fields = append(patch.Fields, field)
+	}
+	sort.Strings(patch.Fields)
+
+	version, ok := h.parseIfMatch(w, req)
+	if !ok {
+		return
+	}
+	patch.Event.Version = version
+
+	event, err := h.app.PatchEvent(req.Context(), id, patch)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	output, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("patchEvent: error while marshaling event", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("patchEvent: error while marshaling event: %s", err))
+		return
+	}
+
+	setETag(w, event.Version)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.logger.Error("patchEvent: error while writing response", map[string]interface{}{"error": err})
+		return
+	}
+}
+
 func (h *Handler) deleteEvent(w http.ResponseWriter, req *http.Request) {
 	id := strings.TrimPrefix(req.URL.Path, "/event/")
 	err := uuid.Validate(id)
 	if err != nil {
 		h.logger.Error("invalid id", map[string]interface{}{"error": err})
-		http.Error(w, err.Error(), 400)
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, ok := h.parseIfMatch(w, req)
+	if !ok {
 		return
 	}
 
-	err = h.app.DeleteEvent(req.Context(), id)
+	err = h.app.DeleteEvent(req.Context(), id, version)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) getListEvents(w http.ResponseWriter, req *http.Request) {
-	query := req.URL.Query()
-	startParam := query.Get("start")
-	if startParam == "" {
-		h.logger.Error("start is required parameter", nil)
-		http.Error(w, "start is required parameter", 400)
+func (h *Handler) getEvent(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/event/")
+	err := uuid.Validate(id)
+	if err != nil {
+		h.logger.Error("invalid id", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	start, err := time.Parse(time.DateOnly, startParam)
+	event, err := h.app.GetEvent(req.Context(), id)
 	if err != nil {
-		h.logger.Error("Invalid start parameter", map[string]interface{}{"error": err})
-		http.Error(w, fmt.Sprintf("invalid start parameter: %s", err), http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	output, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("getEvent: error while marshaling event", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("getEvent: error while marshaling event: %s", err))
+		return
+	}
+
+	setETag(w, event.Version)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.logger.Error("getEvent: error while writing response", map[string]interface{}{"error": err})
+		return
+	}
+}
+
+func (h *Handler) getTrash(w http.ResponseWriter, req *http.Request) {
+	events, err := h.app.GetTrash(req.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.writeEvents(w, events)
+}
+
+func (h *Handler) restoreEvent(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	err := uuid.Validate(id)
+	if err != nil {
+		h.logger.Error("invalid id", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	event, err := h.app.RestoreEvent(req.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	output, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("restoreEvent: error while marshaling event", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("restoreEvent: error while marshaling event: %s", err))
+		return
+	}
+
+	setETag(w, event.Version)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.logger.Error("restoreEvent: error while writing response", map[string]interface{}{"error": err})
+		return
+	}
+}
+
+func (h *Handler) getEventHistory(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	err := uuid.Validate(id)
+	if err != nil {
+		h.logger.Error("invalid id", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := h.app.GetEventHistory(req.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	output, err := json.Marshal(entries)
+	if err != nil {
+		h.logger.Error("getEventHistory: error while marshaling history", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError,
+			fmt.Sprintf("getEventHistory: error while marshaling history: %s", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.logger.Error("getEventHistory: error while writing response", map[string]interface{}{"error": err})
+		return
+	}
+}
+
+func (h *Handler) getListEvents(w http.ResponseWriter, req *http.Request) {
+	start, amountDays, ok := h.parsePeriod(w, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	if query.Has("limit") || query.Has("page_token") {
+		h.getPageOfEvents(w, req, start, amountDays)
 		return
 	}
 
-	amountDaysParam := query.Get("amount_days")
 	var events []models.Event
-	switch amountDaysParam {
-	case "":
+	var err error
+	switch amountDays {
+	case 0:
 		events, err = h.app.GetListEventsDuringDay(req.Context(), start)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("server error: %s", err), 500)
+			writeError(w, err)
 			return
 		}
 	default:
-		amountDays, err := strconv.Atoi(amountDaysParam)
+		events, err = h.app.GetListEventsDuringFewDays(req.Context(), start, amountDays)
 		if err != nil {
-			h.logger.Error("Invalid amount_days parameter", map[string]interface{}{"error": err})
-			http.Error(w, "Invalid amount_days parameter", http.StatusBadRequest)
+			writeError(w, err)
 			return
 		}
+	}
 
-		events, err = h.app.GetListEventsDuringFewDays(req.Context(), start, amountDays)
+	h.writeEvents(w, events)
+}
+
+// getPageOfEvents writes a page of events, the token of the next page is returned in the X-Next-Page-Token header.
+func (h *Handler) getPageOfEvents(w http.ResponseWriter, req *http.Request, start time.Time, amountDays int) {
+	query := req.URL.Query()
+
+	var limit int
+	if limitParam := query.Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
 		if err != nil {
-			http.Error(w, err.Error(), 500)
+			h.logger.Error("Invalid limit parameter", map[string]interface{}{"error": err})
+			writeProblem(w, http.StatusBadRequest, "Invalid limit parameter")
 			return
 		}
 	}
 
+	page, err := h.app.GetListEventsPage(req.Context(), start, amountDays, limit, query.Get("page_token"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if page.NextPageToken != "" {
+		w.Header().Set(nextPageTokenHeader, page.NextPageToken)
+	}
+
+	h.writeEvents(w, page.Events)
+}
+
+func (h *Handler) getListEventsDuringWeek(w http.ResponseWriter, req *http.Request) {
+	day, ok := h.parseDay(w, req, "week", parseISOWeek)
+	if !ok {
+		return
+	}
+
+	events, err := h.app.GetListEventsDuringWeek(req.Context(), day)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.writeEvents(w, events)
+}
+
+func (h *Handler) getListEventsDuringMonth(w http.ResponseWriter, req *http.Request) {
+	day, ok := h.parseDay(w, req, "month", func(month string, loc *time.Location) (time.Time, error) {
+		return time.ParseInLocation(monthLayout, month, loc)
+	})
+	if !ok {
+		return
+	}
+
+	events, err := h.app.GetListEventsDuringMonth(req.Context(), day)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.writeEvents(w, events)
+}
+
+// searchEvents looks for words of q parameter, from and to are dates in the time zone of tz parameter,
+// both days are included.
+func (h *Handler) searchEvents(w http.ResponseWriter, req *http.Request) {
+	loc, ok := h.parseLocation(w, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	searchQuery := models.SearchQuery{Text: query.Get("q"), UserID: query.Get("user_id")}
+	if searchQuery.Text == "" {
+		h.logger.Error("q is required parameter", nil)
+		writeProblem(w, http.StatusBadRequest, "q is required parameter")
+		return
+	}
+
+	searchQuery.From, searchQuery.To, ok = h.parseRange(w, req, loc)
+	if !ok {
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		searchQuery.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			h.logger.Error("Invalid limit parameter", map[string]interface{}{"error": err})
+			writeProblem(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	events, err := h.app.SearchEvents(req.Context(), searchQuery)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.writeEvents(w, events)
+}
+
+func (h *Handler) writeEvents(w http.ResponseWriter, events []models.Event) {
 	output, err := json.Marshal(events)
 	if err != nil {
-		h.logger.Error("getEventsDuringDay: error while marshaling list of events", map[string]interface{}{"error": err})
-		http.Error(w, fmt.Sprintf("getEventsDuringDay: error while marshaling list of events: %s", err), 500)
+		h.logger.Error("error while marshaling list of events", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("error while marshaling list of events: %s", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(output)
+	if err != nil {
+		h.logger.Error("error while writing response", map[string]interface{}{"error": err})
+		return
+	}
+}
+
+func (h *Handler) exportEvents(w http.ResponseWriter, req *http.Request) {
+	start, amountDays, ok := h.parsePeriod(w, req)
+	if !ok {
+		return
+	}
+
+	calendar, err := h.app.ExportEvents(req.Context(), start, amountDays)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	_, err = w.Write(calendar)
+	if err != nil {
+		h.logger.Error("exportEvents: error while writing response", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("exportEvents: error while writing response:%s", err))
+		return
+	}
+}
+
+func (h *Handler) importEvents(w http.ResponseWriter, req *http.Request) {
+	calendar, err := io.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Error("Error while reading request", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ids, err := h.app.ImportEvents(req.Context(), calendar)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	output, err := json.Marshal(ids)
+	if err != nil {
+		h.logger.Error("importEvents: error while marshaling list of ids", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError, fmt.Sprintf("importEvents: error while marshaling list of ids: %s", err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	_, err = w.Write(output)
 	if err != nil {
-		h.logger.Error("getEventsDuringDay: error while writing response", map[string]interface{}{"error": err})
-		http.Error(w, fmt.Sprintf("getEventsDuringDay: error while writing response:%s", err), 500)
+		h.logger.Error("importEvents: error while writing response", map[string]interface{}{"error": err})
 		return
 	}
 }
+
+// parsePeriod reads start, amount_days and tz query parameters, writing 400 response if they are invalid.
+type batchRequest struct {
+	Atomic bool               `json:"atomic"`
+	Items  []models.BatchItem `json:"items"`
+}
+
+type batchResult struct {
+	ID      string `json:"id,omitempty"`
+	Version int64  `json:"version,omitempty"`
+	Status  int    `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// batchEvents applies the batch and reports every item with the status its own request would have.
+// The response is 200 even if items failed, the batch itself fails only for an invalid request.
+func (h *Handler) batchEvents(w http.ResponseWriter, req *http.Request) {
+	var input batchRequest
+	decoder := json.NewDecoder(req.Body)
+	if err := decoder.Decode(&input); err != nil {
+		h.logger.Error("Error while decoding request", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := h.app.ApplyBatch(req.Context(), input.Items, input.Atomic)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	output := make([]batchResult, 0, len(results))
+	for i, result := range results {
+		item := batchResult{ID: result.ID, Version: result.Event.Version, Status: batchStatus(input.Items[i].Operation)}
+		if result.Err != nil {
+			item.Status, item.Error = statusFromError(result.Err), result.Err.Error()
+		}
+
+		output = append(output, item)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"results": output})
+	if err != nil {
+		h.logger.Error("batchEvents: error while marshaling results", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusInternalServerError,
+			fmt.Sprintf("batchEvents: error while marshaling results: %s", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(body)
+	if err != nil {
+		h.logger.Error("batchEvents: error while writing response", map[string]interface{}{"error": err})
+		return
+	}
+}
+
+func batchStatus(operation models.BatchOperation) int {
+	switch operation {
+	case models.BatchCreate:
+		return http.StatusCreated
+	case models.BatchDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
+
+func (h *Handler) parsePeriod(w http.ResponseWriter, req *http.Request) (time.Time, int, bool) {
+	loc, ok := h.parseLocation(w, req)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+
+	query := req.URL.Query()
+	startParam := query.Get("start")
+	if startParam == "" {
+		h.logger.Error("start is required parameter", nil)
+		writeProblem(w, http.StatusBadRequest, "start is required parameter")
+		return time.Time{}, 0, false
+	}
+
+	start, err := time.ParseInLocation(time.DateOnly, startParam, loc)
+	if err != nil {
+		h.logger.Error("Invalid start parameter", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid start parameter: %s", err))
+		return time.Time{}, 0, false
+	}
+
+	amountDaysParam := query.Get("amount_days")
+	if amountDaysParam == "" {
+		return start, 0, true
+	}
+
+	amountDays, err := strconv.Atoi(amountDaysParam)
+	if err != nil {
+		h.logger.Error("Invalid amount_days parameter", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, "Invalid amount_days parameter")
+		return time.Time{}, 0, false
+	}
+
+	return start, amountDays, true
+}
+
+// parseDay reads date query parameter or, if it is absent, the alternative one in the time zone of tz parameter,
+// writing 400 response on failure.
+func (h *Handler) parseDay(
+	w http.ResponseWriter, req *http.Request, alternative string,
+	parse func(string, *time.Location) (time.Time, error),
+) (time.Time, bool) {
+	loc, ok := h.parseLocation(w, req)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	query := req.URL.Query()
+	param, value := alternative, query.Get(alternative)
+	if date := query.Get("date"); date != "" || value == "" {
+		param, value, parse = "date", date, parseDate
+	}
+
+	if value == "" {
+		h.logger.Error("date is required parameter", nil)
+		writeProblem(w, http.StatusBadRequest, fmt.Sprintf("date or %s is required parameter", alternative))
+		return time.Time{}, false
+	}
+
+	day, err := parse(value, loc)
+	if err != nil {
+		h.logger.Error("Invalid "+param+" parameter", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid %s parameter: %s", param, err))
+		return time.Time{}, false
+	}
+
+	return day, true
+}
+
+// parseIfMatch reads the expected version of the event from If-Match header, writing 400 response if it is invalid.
+// Zero version is returned when any version matches.
+func (h *Handler) parseIfMatch(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		h.logger.Error("Invalid If-Match header", map[string]interface{}{"If-Match": ifMatch})
+		writeProblem(w, http.StatusBadRequest, "If-Match must be a single entity tag of the event")
+		return 0, false
+	}
+
+	return version, true
+}
+
+// setETag sets the version of the event as a strong entity tag.
+func setETag(w http.ResponseWriter, version int64) {
+	if version != 0 {
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+	}
+}
+
+// parseRange parses optional from and to dates, the returned range ends after the to date.
+func (h *Handler) parseRange(
+	w http.ResponseWriter, req *http.Request, loc *time.Location,
+) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	query := req.URL.Query()
+	if value := query.Get("from"); value != "" {
+		day, err := parseDate(value, loc)
+		if err != nil {
+			h.logger.Error("Invalid from parameter", map[string]interface{}{"error": err})
+			writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid from parameter: %s", err))
+			return time.Time{}, time.Time{}, false
+		}
+		from = day
+	}
+
+	if value := query.Get("to"); value != "" {
+		day, err := parseDate(value, loc)
+		if err != nil {
+			h.logger.Error("Invalid to parameter", map[string]interface{}{"error": err})
+			writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid to parameter: %s", err))
+			return time.Time{}, time.Time{}, false
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+
+	return from, to, true
+}
+
+// parseLocation reads tz query parameter with IANA time zone, UTC is used by default.
+func (h *Handler) parseLocation(w http.ResponseWriter, req *http.Request) (*time.Location, bool) {
+	tz := req.URL.Query().Get("tz")
+	if tz == "" {
+		return time.UTC, true
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		h.logger.Error("Invalid tz parameter", map[string]interface{}{"error": err})
+		writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid tz parameter: %s", err))
+		return nil, false
+	}
+
+	return loc, true
+}
+
+func parseDate(date string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, date, loc)
+}
+
+// parseISOWeek converts ISO 8601 week like 2024-W09 to its Monday.
+func parseISOWeek(week string, loc *time.Location) (time.Time, error) {
+	matches := isoWeekRegexp.FindStringSubmatch(week)
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("week %q does not match YYYY-Www", week)
+	}
+
+	year, _ := strconv.Atoi(matches[1])
+	number, _ := strconv.Atoi(matches[2])
+
+	return app.ISOWeekStart(year, number, loc)
+}
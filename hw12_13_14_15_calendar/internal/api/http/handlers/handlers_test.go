@@ -2,15 +2,24 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	mockservice "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/mocks"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -96,29 +105,29 @@ func TestGetListEvents(t *testing.T) {
 			},
 			getParams:           fmt.Sprintf("?start=%s&amount_days=3", testParamTime),
 			expectedStatusCode:  500,
-			expectedRequestBody: "server error\n",
+			expectedRequestBody: problemBody(500, "server error"),
 		},
 		{
 			name:                "No start time in input",
 			mockBehavior:        func(_ *mockservice.MockApplicationInterface) {},
 			getParams:           "",
 			expectedStatusCode:  400,
-			expectedRequestBody: "start is required parameter\n",
+			expectedRequestBody: problemBody(400, "start is required parameter"),
 		},
 		{
 			name:               "invalid start time in input",
 			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
 			getParams:          "?start=invalidTime",
 			expectedStatusCode: 400,
-			//nolint: lll
-			expectedRequestBody: "invalid start parameter: parsing time \"invalidTime\" as \"2006-01-02\": cannot parse \"invalidTime\" as \"2006\"\n",
+			expectedRequestBody: problemBody(400,
+				`invalid start parameter: parsing time "invalidTime" as "2006-01-02": cannot parse "invalidTime" as "2006"`),
 		},
 		{
 			name:                "invalid amount days in input",
 			mockBehavior:        func(_ *mockservice.MockApplicationInterface) {},
 			getParams:           fmt.Sprintf("?start=%s&amount_days=a", testParamTime),
 			expectedStatusCode:  400,
-			expectedRequestBody: "Invalid amount_days parameter\n",
+			expectedRequestBody: problemBody(400, "Invalid amount_days parameter"),
 		},
 	}
 
@@ -136,6 +145,7 @@ func TestGetListEvents(t *testing.T) {
 			r := handler.InitRoutes()
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", fmt.Sprintf("/event/list%s", testCase.getParams), nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
 
 			r.ServeHTTP(w, req)
 
@@ -145,6 +155,306 @@ func TestGetListEvents(t *testing.T) {
 	}
 }
 
+func TestGetPageOfEvents(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	testDay := time.Date(2024, time.February, 14, 0, 0, 0, 0, time.UTC)
+	testEvents := []models.Event{{ID: testEventID, Header: "test1", UserID: testUserID, EventTime: testTime}}
+	testBody := fmt.Sprintf(`[{"id":"%s","header":"test1","description":"","userId":"%s","eventTime":"%s"}]`,
+		testEventID, testUserID, testTime.Format(time.RFC3339Nano))
+
+	testTable := []struct {
+		name                  string
+		mockBehavior          mockBehavior
+		getParams             string
+		expectedStatusCode    int
+		expectedBody          string
+		expectedNextPageToken string
+	}{
+		{
+			name: "first page",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsPage(gomock.Any(), testDay, 30, 1, "").
+					Return(app.Page{Events: testEvents, NextPageToken: "next"}, nil)
+			},
+			getParams:             "?start=2024-02-14&amount_days=30&limit=1",
+			expectedStatusCode:    200,
+			expectedBody:          testBody,
+			expectedNextPageToken: "next",
+		},
+		{
+			name: "last page",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsPage(gomock.Any(), testDay, 0, 0, "next").
+					Return(app.Page{Events: testEvents}, nil)
+			},
+			getParams:          "?start=2024-02-14&page_token=next",
+			expectedStatusCode: 200,
+			expectedBody:       testBody,
+		},
+		{
+			name: "invalid page token",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsPage(gomock.Any(), testDay, 0, 0, "invalid").
+					Return(app.Page{}, fmt.Errorf("%w: invalid page token", app.ErrInvalidArgument))
+			},
+			getParams:          "?start=2024-02-14&page_token=invalid",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid argument: invalid page token"),
+		},
+		{
+			name:               "invalid limit",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			getParams:          "?start=2024-02-14&limit=a",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "Invalid limit parameter"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", fmt.Sprintf("/event/list%s", testCase.getParams), nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+			assert.Equal(t, testCase.expectedNextPageToken, w.Header().Get(nextPageTokenHeader))
+		})
+	}
+}
+
+func TestSearchEvents(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	testEvents := []models.Event{{ID: testEventID, Header: "sprint", UserID: testUserID, EventTime: testTime}}
+	testBody := fmt.Sprintf(`[{"id":"%s","header":"sprint","description":"","userId":"%s","eventTime":"%s"}]`,
+		testEventID, testUserID, testTime.Format(time.RFC3339Nano))
+
+	testTable := []struct {
+		name               string
+		mockBehavior       mockBehavior
+		getParams          string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().SearchEvents(gomock.Any(), models.SearchQuery{
+					Text:   "sprint planning",
+					UserID: testUserID,
+					From:   time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+					To:     time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+					Limit:  10,
+				}).Return(testEvents, nil)
+			},
+			getParams: fmt.Sprintf("?q=sprint+planning&user_id=%s&from=2024-02-01&to=2024-02-29&limit=10",
+				testUserID),
+			expectedStatusCode: 200,
+			expectedBody:       testBody,
+		},
+		{
+			name: "empty query",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().SearchEvents(gomock.Any(), models.SearchQuery{Text: "!!!"}).
+					Return(nil, fmt.Errorf("%w: search query must contain words", app.ErrInvalidArgument))
+			},
+			getParams:          "?q=!!!",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid argument: search query must contain words"),
+		},
+		{
+			name:               "no query",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			getParams:          "",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "q is required parameter"),
+		},
+		{
+			name:               "invalid from",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			getParams:          "?q=sprint&from=yesterday",
+			expectedStatusCode: 400,
+			expectedBody: problemBody(400,
+				`invalid from parameter: parsing time "yesterday" as "2006-01-02": cannot parse "yesterday" as "2006"`),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", fmt.Sprintf("/event/search%s", testCase.getParams), nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestGetListEventsByPeriod(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	testEvents := []models.Event{{ID: testEventID, Header: "test1", UserID: testUserID, EventTime: testTime}}
+	testBody := fmt.Sprintf(`[{"id":"%s","header":"test1","description":"","userId":"%s","eventTime":"%s"}]`,
+		testEventID, testUserID, testTime.Format(time.RFC3339Nano))
+
+	testTable := []struct {
+		name               string
+		mockBehavior       mockBehavior
+		path               string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "week by date",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsDuringWeek(gomock.Any(), time.Date(2024, time.February, 14, 0, 0, 0, 0, time.UTC)).
+					Return(testEvents, nil)
+			},
+			path:               "/event/week?date=2024-02-14",
+			expectedStatusCode: 200,
+			expectedBody:       testBody,
+		},
+		{
+			name: "ISO week",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsDuringWeek(gomock.Any(), time.Date(2024, time.February, 12, 0, 0, 0, 0, time.UTC)).
+					Return(testEvents, nil)
+			},
+			path:               "/event/week?week=2024-W07",
+			expectedStatusCode: 200,
+			expectedBody:       testBody,
+		},
+		{
+			name: "week in time zone",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsDuringWeek(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, day time.Time) ([]models.Event, error) {
+						tokyo, err := time.LoadLocation("Asia/Tokyo")
+						require.NoError(t, err)
+						require.Equal(t, time.Date(2024, time.February, 12, 0, 0, 0, 0, tokyo).Unix(), day.Unix())
+						require.Equal(t, "Asia/Tokyo", day.Location().String())
+
+						return testEvents, nil
+					})
+			},
+			path:               "/event/week?week=2024-W07&tz=Asia/Tokyo",
+			expectedStatusCode: 200,
+			expectedBody:       testBody,
+		},
+		{
+			name:               "invalid time zone",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			path:               "/event/week?date=2024-02-14&tz=Mars/Olympus",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid tz parameter: unknown time zone Mars/Olympus"),
+		},
+		{
+			name:               "week that does not exist",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			path:               "/event/week?week=2021-W53",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid week parameter: invalid argument: week 53 does not exist in 2021"),
+		},
+		{
+			name:               "invalid week",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			path:               "/event/week?week=2024-7",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, `invalid week parameter: week "2024-7" does not match YYYY-Www`),
+		},
+		{
+			name:               "no date of week",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			path:               "/event/week",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "date or week is required parameter"),
+		},
+		{
+			name: "month",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsDuringMonth(gomock.Any(), time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)).
+					Return(testEvents, nil)
+			},
+			path:               "/event/month?month=2024-02",
+			expectedStatusCode: 200,
+			expectedBody:       testBody,
+		},
+		{
+			name: "month server error",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetListEventsDuringMonth(gomock.Any(), testTime.Truncate(24*time.Hour)).
+					Return(nil, errors.New("server error"))
+			},
+			path:               "/event/month?date=2024-02-14",
+			expectedStatusCode: 500,
+			expectedBody:       problemBody(500, "server error"),
+		},
+		{
+			name:               "invalid month",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			path:               "/event/month?month=2024-13",
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, `invalid month parameter: parsing time "2024-13": month out of range`),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.path, nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
 func TestCreateEvent(t *testing.T) {
 	type mockBehavior func(s *mockservice.MockApplicationInterface, eventDTO models.Event)
 	testUserID := uuid.New().String()
@@ -195,6 +505,24 @@ func TestCreateEvent(t *testing.T) {
 
 			expectedStatusCode: 500,
 		},
+		{
+			name: "date busy",
+			inputBody: fmt.Sprintf(`{"header":"test1","description":"testDescription1","userId":"%s","eventTime":"%s"}`,
+				testUserID, testTimeForJSON),
+			inputEvent: models.Event{
+				Header:           "test1",
+				Description:      "testDescription1",
+				UserID:           testUserID,
+				EventTime:        testTime,
+				FinishEventTime:  nil,
+				NotificationTime: nil,
+			},
+			mockBehavior: func(s *mockservice.MockApplicationInterface, eventDTO models.Event) {
+				s.EXPECT().CreateEvent(gomock.Any(), eventDTO).Return("", app.ErrDateBusy)
+			},
+
+			expectedStatusCode: 409,
+		},
 		{
 			name: "invalid input",
 			inputBody: fmt.Sprintf(`{"header":"test1","description":"testDescription1","userId":2,"eventTime":"%s"}`,
@@ -218,6 +546,7 @@ func TestCreateEvent(t *testing.T) {
 			r := handler.InitRoutes()
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("POST", "/event", bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(UserIDHeader, uuid.New().String())
 
 			r.ServeHTTP(w, req)
 
@@ -306,6 +635,7 @@ func TestUpdateEvent(t *testing.T) {
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest(
 				"PUT", fmt.Sprintf("/event/%v", testCase.pathID), bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(UserIDHeader, uuid.New().String())
 
 			r.ServeHTTP(w, req)
 
@@ -330,7 +660,7 @@ func TestDeleteEvent(t *testing.T) {
 			pathID: testEventID,
 			id:     testEventID,
 			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
-				s.EXPECT().DeleteEvent(gomock.Any(), id).Return(nil)
+				s.EXPECT().DeleteEvent(gomock.Any(), id, int64(0)).Return(nil)
 			},
 
 			expectedStatusCode: 204,
@@ -340,11 +670,31 @@ func TestDeleteEvent(t *testing.T) {
 			pathID: testEventID,
 			id:     testEventID,
 			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
-				s.EXPECT().DeleteEvent(gomock.Any(), id).Return(errors.New("server error"))
+				s.EXPECT().DeleteEvent(gomock.Any(), id, int64(0)).Return(errors.New("server error"))
 			},
 
 			expectedStatusCode: 500,
 		},
+		{
+			name:   "not found",
+			pathID: testEventID,
+			id:     testEventID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().DeleteEvent(gomock.Any(), id, int64(0)).Return(app.ErrEventNotFound)
+			},
+
+			expectedStatusCode: 404,
+		},
+		{
+			name:   "event of another user",
+			pathID: testEventID,
+			id:     testEventID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().DeleteEvent(gomock.Any(), id, int64(0)).Return(app.ErrEventForbidden)
+			},
+
+			expectedStatusCode: 403,
+		},
 		{
 			name:               "invalid input",
 			pathID:             1,
@@ -365,6 +715,7 @@ func TestDeleteEvent(t *testing.T) {
 			r := handler.InitRoutes()
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("DELETE", fmt.Sprintf("/event/%v", testCase.pathID), nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
 
 			r.ServeHTTP(w, req)
 
@@ -372,3 +723,747 @@ func TestDeleteEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEvent(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface, id string)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Now()
+
+	testTable := []struct {
+		name               string
+		pathID             interface{}
+		id                 string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:   "OK",
+			pathID: testEventID,
+			id:     testEventID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().GetEvent(gomock.Any(), id).Return(models.Event{
+					ID:          id,
+					Header:      "test1",
+					Description: "testDescription1",
+					UserID:      testUserID,
+					EventTime:   testTime,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(
+				`{"id":"%s","header":"test1","description":"testDescription1","userId":"%s","eventTime":"%s"}`,
+				testEventID, testUserID, testTime.Format(time.RFC3339Nano)),
+		},
+		{
+			name:   "not found",
+			pathID: testEventID,
+			id:     testEventID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().GetEvent(gomock.Any(), id).Return(models.Event{}, app.ErrEventNotFound)
+			},
+			expectedStatusCode: 404,
+			expectedBody:       problemBody(404, app.ErrEventNotFound.Error()),
+		},
+		{
+			name:   "event of another user",
+			pathID: testEventID,
+			id:     testEventID,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, id string) {
+				s.EXPECT().GetEvent(gomock.Any(), id).Return(models.Event{}, app.ErrEventForbidden)
+			},
+			expectedStatusCode: 403,
+			expectedBody:       problemBody(403, app.ErrEventForbidden.Error()),
+		},
+		{
+			name:               "invalid input",
+			pathID:             1,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface, _ string) {},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid UUID length: 1"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface, testCase.id)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", fmt.Sprintf("/event/%v", testCase.pathID), nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestExportEvents(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testParamTime := time.Now().Format(time.DateOnly)
+	testDateOnly, _ := time.Parse(time.DateOnly, testParamTime)
+	testCalendar := []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+
+	testTable := []struct {
+		name                string
+		mockBehavior        mockBehavior
+		getParams           string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().ExportEvents(gomock.Any(), testDateOnly, 7).Return(testCalendar, nil)
+			},
+			getParams:           fmt.Sprintf("?start=%s&amount_days=7", testParamTime),
+			expectedStatusCode:  200,
+			expectedContentType: "text/calendar; charset=utf-8",
+			expectedBody:        string(testCalendar),
+		},
+		{
+			name: "server error",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().ExportEvents(gomock.Any(), testDateOnly, 0).Return(nil, errors.New("server error"))
+			},
+			getParams:           fmt.Sprintf("?start=%s", testParamTime),
+			expectedStatusCode:  500,
+			expectedContentType: "application/problem+json",
+			expectedBody:        problemBody(500, "server error"),
+		},
+		{
+			name:                "No start time in input",
+			mockBehavior:        func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode:  400,
+			expectedContentType: "application/problem+json",
+			expectedBody:        problemBody(400, "start is required parameter"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", fmt.Sprintf("/event/export%s", testCase.getParams), nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestImportEvents(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface, calendar []byte)
+	testEventID := uuid.New().String()
+	testCalendar := "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"
+
+	testTable := []struct {
+		name               string
+		inputBody          string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:      "OK",
+			inputBody: testCalendar,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, calendar []byte) {
+				s.EXPECT().ImportEvents(gomock.Any(), calendar).Return([]string{testEventID}, nil)
+			},
+			expectedStatusCode: 201,
+			expectedBody:       fmt.Sprintf(`["%s"]`, testEventID),
+		},
+		{
+			name:      "server error",
+			inputBody: testCalendar,
+			mockBehavior: func(s *mockservice.MockApplicationInterface, calendar []byte) {
+				s.EXPECT().ImportEvents(gomock.Any(), calendar).Return(nil, errors.New("server error"))
+			},
+			expectedStatusCode: 500,
+			expectedBody:       problemBody(500, "server error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface, []byte(testCase.inputBody))
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/event/import", bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+// TestCalendarRoundTrip imports a raw iCalendar file and exports it back with the real application.
+func TestCalendarRoundTrip(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	calendar := app.New(logg, memorystorage.New(logg), time.Monday)
+	defer calendar.Close()
+	r := NewHandler(logg, calendar).InitRoutes()
+	userID := uuid.New().String()
+	file := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:first@test\r\nDTSTART:20240214T100000Z\r\nDTEND:20240214T110000Z\r\n" +
+		"SUMMARY:Planning\r\nDESCRIPTION:Sprint\\, goals\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:second@test\r\nDTSTART:20240215T090000Z\r\nSUMMARY:Review\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/event/import", bytes.NewBufferString(file))
+	req.Header.Set("Content-Type", ical.ContentType)
+	req.Header.Set(UserIDHeader, userID)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var ids []string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ids))
+	require.Len(t, ids, 2)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/event/export?start=2024-02-14&amount_days=7", nil)
+	req.Header.Set(UserIDHeader, userID)
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, ical.ContentType, w.Header().Get("Content-Type"))
+
+	imported, err := ical.Decode(strings.NewReader(file))
+	require.NoError(t, err)
+	exported, err := ical.Decode(w.Body)
+	require.NoError(t, err)
+	require.Len(t, exported, len(imported))
+	sort.Slice(exported, func(i, j int) bool { return exported[i].EventTime.Before(exported[j].EventTime) })
+	for i := range imported {
+		require.Equal(t, imported[i].Header, exported[i].Header)
+		require.Equal(t, imported[i].Description, exported[i].Description)
+		require.True(t, imported[i].EventTime.Equal(exported[i].EventTime), "event time %v", exported[i].EventTime)
+		require.Equal(t, imported[i].FinishEventTime == nil, exported[i].FinishEventTime == nil)
+	}
+}
+
+func TestIdentityMiddleware(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	appInterface := mockservice.NewMockApplicationInterface(c)
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	handler := NewHandler(logg, appInterface)
+	r := handler.InitRoutes()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", fmt.Sprintf("/event/list?start=%s", time.Now().Format(time.DateOnly)), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 401, w.Code)
+	assert.Equal(t, problemBody(401, "X-User-ID header is required"), w.Body.String())
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/hello", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+}
+
+func problemBody(status int, detail string) string {
+	body, _ := json.Marshal(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+
+	return string(body)
+}
+
+func TestPatchEvent(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		pathID             string
+		body               string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:   "OK",
+			pathID: testEventID,
+			body:   `{"eventTime":"2024-02-14T10:00:00Z","notificationTime":null}`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, models.EventPatch{
+					Event:  models.Event{EventTime: testTime},
+					Fields: []string{"eventTime", "notificationTime"},
+				}).Return(models.Event{ID: testEventID, Header: "test1", UserID: testUserID, EventTime: testTime}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(`{"id":"%s","header":"test1","description":"","userId":"%s","eventTime":"%s"}`,
+				testEventID, testUserID, testTime.Format(time.RFC3339Nano)),
+		},
+		{
+			name:   "field can not be patched",
+			pathID: testEventID,
+			body:   `{"userId":"someone"}`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, models.EventPatch{
+					Event:  models.Event{UserID: "someone"},
+					Fields: []string{"userId"},
+				}).Return(models.Event{}, fmt.Errorf("%w: field \"userId\" can not be patched", app.ErrInvalidArgument))
+			},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, `invalid argument: field "userId" can not be patched`),
+		},
+		{
+			name:   "not found",
+			pathID: testEventID,
+			body:   `{"header":"new"}`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, gomock.Any()).Return(models.Event{}, app.ErrEventNotFound)
+			},
+			expectedStatusCode: 404,
+			expectedBody:       problemBody(404, app.ErrEventNotFound.Error()),
+		},
+		{
+			name:               "not an object",
+			pathID:             testEventID,
+			body:               `["header"]`,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "merge patch must be a JSON object"),
+		},
+		{
+			name:               "invalid id",
+			pathID:             "1",
+			body:               `{}`,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid UUID length: 1"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/event/%s", testCase.pathID),
+				bytes.NewBufferString(testCase.body))
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestEventVersions(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		method             string
+		body               string
+		ifMatch            string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedETag       string
+	}{
+		{
+			name:   "get returns ETag",
+			method: http.MethodGet,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetEvent(gomock.Any(), testEventID).
+					Return(models.Event{ID: testEventID, EventTime: testTime, Version: 7}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedETag:       `"7"`,
+		},
+		{
+			name:    "update with If-Match",
+			method:  http.MethodPut,
+			body:    `{"header":"new","version":1}`,
+			ifMatch: `"7"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().UpdateEvent(gomock.Any(), models.Event{ID: testEventID, Header: "new", Version: 7}).Return(nil)
+			},
+			expectedStatusCode: 204,
+			expectedETag:       `"8"`,
+		},
+		{
+			name:    "patch returns new ETag",
+			method:  http.MethodPatch,
+			body:    `{"header":"new"}`,
+			ifMatch: `"7"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().PatchEvent(gomock.Any(), testEventID, models.EventPatch{
+					Event:  models.Event{Header: "new", Version: 7},
+					Fields: []string{"header"},
+				}).Return(models.Event{ID: testEventID, Header: "new", EventTime: testTime, Version: 8}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedETag:       `"8"`,
+		},
+		{
+			name:    "delete of changed event",
+			method:  http.MethodDelete,
+			ifMatch: `"7"`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().DeleteEvent(gomock.Any(), testEventID, int64(7)).Return(app.ErrVersionMismatch)
+			},
+			expectedStatusCode: 412,
+		},
+		{
+			name:    "delete of any version",
+			method:  http.MethodDelete,
+			ifMatch: "*",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().DeleteEvent(gomock.Any(), testEventID, int64(0)).Return(nil)
+			},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "weak entity tag",
+			method:             http.MethodDelete,
+			ifMatch:            `W/"7"`,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, fmt.Sprintf("/event/%s", testEventID),
+				bytes.NewBufferString(testCase.body))
+			req.Header.Set(UserIDHeader, uuid.New().String())
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedETag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestTrash(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	deletedAt := testTime.Add(time.Hour)
+
+	testTable := []struct {
+		name               string
+		method             string
+		path               string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:   "trash",
+			method: http.MethodGet,
+			path:   "/event/trash",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetTrash(gomock.Any()).Return([]models.Event{{
+					ID: testEventID, Header: "test1", UserID: testUserID, EventTime: testTime, DeletedAt: &deletedAt,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(
+				`[{"id":"%s","header":"test1","description":"","userId":"%s","eventTime":"%s","deletedAt":"%s"}]`,
+				testEventID, testUserID, testTime.Format(time.RFC3339Nano), deletedAt.Format(time.RFC3339Nano)),
+		},
+		{
+			name:   "restore",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/event/%s/restore", testEventID),
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().RestoreEvent(gomock.Any(), testEventID).
+					Return(models.Event{ID: testEventID, Header: "test1", UserID: testUserID, EventTime: testTime}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(`{"id":"%s","header":"test1","description":"","userId":"%s","eventTime":"%s"}`,
+				testEventID, testUserID, testTime.Format(time.RFC3339Nano)),
+		},
+		{
+			name:   "restore of event not in the trash",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/event/%s/restore", testEventID),
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().RestoreEvent(gomock.Any(), testEventID).Return(models.Event{}, app.ErrEventNotDeleted)
+			},
+			expectedStatusCode: 409,
+			expectedBody:       problemBody(409, app.ErrEventNotDeleted.Error()),
+		},
+		{
+			name:               "restore with invalid id",
+			method:             http.MethodPost,
+			path:               "/event/1/restore",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid UUID length: 1"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.path, nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestGetEventHistory(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testUserID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		path               string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "history",
+			path: fmt.Sprintf("/event/%s/history", testEventID),
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetEventHistory(gomock.Any(), testEventID).Return([]models.AuditEntry{{
+					ID: 1, EventID: testEventID, UserID: testUserID, Actor: testUserID, Action: models.AuditCreate,
+					After: &models.Event{ID: testEventID, Header: "test1", UserID: testUserID, EventTime: testTime},
+					Time:  testTime,
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(`[{"id":1,"eventId":"%[1]s","userId":"%[2]s","actor":"%[2]s","action":"create",`+
+				`"after":{"id":"%[1]s","header":"test1","description":"","userId":"%[2]s","eventTime":"%[3]s"},`+
+				`"time":"%[3]s"}]`, testEventID, testUserID, testTime.Format(time.RFC3339Nano)),
+		},
+		{
+			name: "history of another user's event",
+			path: fmt.Sprintf("/event/%s/history", testEventID),
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().GetEventHistory(gomock.Any(), testEventID).Return(nil, app.ErrEventForbidden)
+			},
+			expectedStatusCode: 403,
+			expectedBody:       problemBody(403, app.ErrEventForbidden.Error()),
+		},
+		{
+			name:               "invalid id",
+			path:               "/event/1/history",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid UUID length: 1"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestBatchEvents(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		inputBody          string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "results of every item",
+			inputBody: fmt.Sprintf(`{"atomic":false,"items":[`+
+				`{"op":"create","event":{"header":"test1","eventTime":"%[1]s"}},`+
+				`{"op":"update","event":{"id":"%[2]s","header":"test2","eventTime":"%[1]s"}},`+
+				`{"op":"delete","event":{"id":"%[2]s","version":3}}]}`,
+				testTime.Format(time.RFC3339), testEventID),
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().ApplyBatch(gomock.Any(), []models.BatchItem{
+					{Operation: models.BatchCreate, Event: models.Event{Header: "test1", EventTime: testTime}},
+					{Operation: models.BatchUpdate, Event: models.Event{ID: testEventID, Header: "test2", EventTime: testTime}},
+					{Operation: models.BatchDelete, Event: models.Event{ID: testEventID, Version: 3}},
+				}, false).Return([]models.BatchResult{
+					{ID: "newID", Event: models.Event{ID: "newID", Version: 1}},
+					{ID: testEventID, Event: models.Event{ID: testEventID, Version: 3}},
+					{ID: testEventID, Err: app.ErrVersionMismatch},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(`{"results":[{"id":"newID","version":1,"status":201},`+
+				`{"id":"%[1]s","version":3,"status":200},{"id":"%[1]s","status":412,"error":"%[2]s"}]}`,
+				testEventID, app.ErrVersionMismatch.Error()),
+		},
+		{
+			name:      "rolled back batch",
+			inputBody: `{"atomic":true,"items":[{"op":"create","event":{"header":"test1"}},{"op":"move"}]}`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().ApplyBatch(gomock.Any(), gomock.Any(), true).Return([]models.BatchResult{
+					{Err: app.ErrBatchAborted},
+					{Err: fmt.Errorf("%w: unknown batch operation \"move\"", app.ErrInvalidArgument)},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf(`{"results":[{"status":409,"error":"%s"},`+
+				`{"status":400,"error":"invalid argument: unknown batch operation \"move\""}]}`,
+				app.ErrBatchAborted.Error()),
+		},
+		{
+			name:      "empty batch",
+			inputBody: `{"items":[]}`,
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().ApplyBatch(gomock.Any(), []models.BatchItem{}, false).
+					Return(nil, fmt.Errorf("%w: batch is empty", app.ErrInvalidArgument))
+			},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid argument: batch is empty"),
+		},
+		{
+			name:               "invalid body",
+			inputBody:          `[`,
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "unexpected EOF"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/event/batch", bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(UserIDHeader, uuid.New().String())
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestValidationProblem(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	appInterface := mockservice.NewMockApplicationInterface(c)
+	validationErr := validator.ValidationErrors{
+		{Field: "header", Err: fmt.Errorf("%w 255", validator.ErrMoreThanMax)},
+		{Field: "finishEventTime", Err: fmt.Errorf("%w eventTime", validator.ErrNotAfter)},
+	}
+	appInterface.EXPECT().CreateEvent(gomock.Any(), gomock.Any()).
+		Return("", fmt.Errorf("%w: %w", app.ErrInvalidArgument, validationErr))
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	r := NewHandler(logg, appInterface).InitRoutes()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/event", bytes.NewBufferString(`{"header":"test"}`))
+	req.Header.Set(UserIDHeader, uuid.New().String())
+
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,`+
+		`"detail":"invalid argument: header: value is more than maximum 255; finishEventTime: value is not after eventTime",`+
+		`"invalid-params":[{"name":"header","reason":"value is more than maximum 255"},`+
+		`{"name":"finishEventTime","reason":"value is not after eventTime"}]}`, w.Body.String())
+}
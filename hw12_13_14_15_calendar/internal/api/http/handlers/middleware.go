@@ -26,6 +26,23 @@ func loggingMiddleware(logger app.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+const UserIDHeader = "X-User-ID"
+
+func identityMiddleware(logger app.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Header.Get(UserIDHeader)
+			if userID == "" {
+				logger.Error("request without user id", map[string]interface{}{"path": r.URL.Path})
+				writeProblem(w, http.StatusUnauthorized, fmt.Sprintf("%s header is required", UserIDHeader))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(app.ContextWithUserID(r.Context(), userID)))
+		})
+	}
+}
+
 type LoggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -39,3 +56,8 @@ func (lrw *LoggingResponseWriter) WriteHeader(code int) {
 	lrw.statusCode = code
 	lrw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the flusher and deadlines of the wrapped writer.
+func (lrw *LoggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
@@ -3,6 +3,7 @@ package handlers
 //nolint:depguard
 import (
 	"net/http"
+	"time"
 
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
@@ -12,10 +13,12 @@ import (
 type Handler struct {
 	logger app.Logger
 	app    api.ApplicationInterface
+	// heartbeat is the interval of comments sent to idle event streams.
+	heartbeat time.Duration
 }
 
 func NewHandler(logger app.Logger, app api.ApplicationInterface) *Handler {
-	return &Handler{logger: logger, app: app}
+	return &Handler{logger: logger, app: app, heartbeat: streamHeartbeatInterval}
 }
 
 func (h *Handler) InitRoutes() *mux.Router {
@@ -24,10 +27,25 @@ func (h *Handler) InitRoutes() *mux.Router {
 
 	r.HandleFunc("/hello", h.helloHandler).Methods(http.MethodGet)
 
-	r.HandleFunc("/event", h.createEvent).Methods(http.MethodPost)
-	r.HandleFunc("/event/{id}", h.updateEvent).Methods(http.MethodPut)
-	r.HandleFunc("/event/{id}", h.deleteEvent).Methods(http.MethodDelete)
-	r.HandleFunc("/event/list", h.getListEvents).Methods(http.MethodGet)
+	events := r.PathPrefix("/event").Subrouter()
+	events.Use(identityMiddleware(h.logger))
+
+	events.HandleFunc("", h.createEvent).Methods(http.MethodPost)
+	events.HandleFunc("/{id}", h.updateEvent).Methods(http.MethodPut)
+	events.HandleFunc("/{id}", h.patchEvent).Methods(http.MethodPatch)
+	events.HandleFunc("/{id}", h.deleteEvent).Methods(http.MethodDelete)
+	events.HandleFunc("/list", h.getListEvents).Methods(http.MethodGet)
+	events.HandleFunc("/week", h.getListEventsDuringWeek).Methods(http.MethodGet)
+	events.HandleFunc("/month", h.getListEventsDuringMonth).Methods(http.MethodGet)
+	events.HandleFunc("/search", h.searchEvents).Methods(http.MethodGet)
+	events.HandleFunc("/trash", h.getTrash).Methods(http.MethodGet)
+	events.HandleFunc("/{id}/restore", h.restoreEvent).Methods(http.MethodPost)
+	events.HandleFunc("/{id}/history", h.getEventHistory).Methods(http.MethodGet)
+	events.HandleFunc("/export", h.exportEvents).Methods(http.MethodGet)
+	events.HandleFunc("/import", h.importEvents).Methods(http.MethodPost)
+	events.HandleFunc("/batch", h.batchEvents).Methods(http.MethodPost)
+	events.HandleFunc("/stream", h.streamEvents).Methods(http.MethodGet)
+	events.HandleFunc("/{id}", h.getEvent).Methods(http.MethodGet)
 
 	return r
 }
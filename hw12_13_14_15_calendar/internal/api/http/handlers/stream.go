@@ -0,0 +1,104 @@
+package handlers
+
+//nolint:depguard
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	lastEventIDHeader       = "Last-Event-ID"
+)
+
+// streamEvents pushes changes of events of the user as server-sent events. The id of every event
+// is the revision of the change, so a reconnecting EventSource resumes with the Last-Event-ID header.
+// Optional from and to dates restrict the changes to events of the window.
+func (h *Handler) streamEvents(w http.ResponseWriter, req *http.Request) {
+	loc, ok := h.parseLocation(w, req)
+	if !ok {
+		return
+	}
+
+	from, to, ok := h.parseRange(w, req, loc)
+	if !ok {
+		return
+	}
+
+	var revision int64
+	if lastEventID := req.Header.Get(lastEventIDHeader); lastEventID != "" {
+		var err error
+		revision, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			h.logger.Error("Invalid Last-Event-ID header", map[string]interface{}{"error": err})
+			writeProblem(w, http.StatusBadRequest, fmt.Sprintf("invalid %s header: %s", lastEventIDHeader, err))
+			return
+		}
+	}
+
+	changes, err := h.app.WatchEvents(req.Context(), revision)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	// The stream outlives the write timeout of the server.
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn("streamEvents: error while resetting write deadline", map[string]interface{}{"error": err})
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		h.logger.Error("streamEvents: error while flushing response", map[string]interface{}{"error": err})
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case change, ok := <-changes:
+			// The client reconnects after the end of the stream.
+			if !ok {
+				return
+			}
+
+			if !change.Within(from, to) {
+				continue
+			}
+
+			data, err := json.Marshal(change)
+			if err != nil {
+				h.logger.Error("streamEvents: error while marshaling change", map[string]interface{}{"error": err})
+				return
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Revision, change.Type, data)
+			if err == nil {
+				err = controller.Flush()
+			}
+
+			if err != nil {
+				h.logger.Error("streamEvents: error while writing change", map[string]interface{}{"error": err})
+				return
+			}
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			if err == nil {
+				err = controller.Flush()
+			}
+
+			if err != nil {
+				h.logger.Error("streamEvents: error while writing heartbeat", map[string]interface{}{"error": err})
+				return
+			}
+		}
+	}
+}
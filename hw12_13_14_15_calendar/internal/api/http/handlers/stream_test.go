@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockservice "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/api/mocks"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamEvents(t *testing.T) {
+	type mockBehavior func(s *mockservice.MockApplicationInterface)
+	testEventID := uuid.New().String()
+	testTime := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	changes := func(changes ...models.Change) <-chan models.Change {
+		ch := make(chan models.Change, len(changes))
+		for _, change := range changes {
+			ch <- change
+		}
+		close(ch)
+
+		return ch
+	}
+
+	testTable := []struct {
+		name               string
+		path               string
+		lastEventID        string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name: "changes",
+			path: "/event/stream",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().WatchEvents(gomock.Any(), int64(0)).Return(changes(
+					models.Change{Revision: 3, Type: models.ChangeCreated, Event: models.Event{
+						ID: testEventID, Header: "test1", EventTime: testTime,
+					}, Time: testTime},
+					models.Change{Revision: 4, Type: models.ChangeDeleted, Event: models.Event{
+						ID: testEventID, Header: "test1", EventTime: testTime,
+					}, Time: testTime},
+				), nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf("id: 3\nevent: created\ndata: {\"revision\":3,\"type\":\"created\","+
+				"\"event\":{\"id\":\"%[1]s\",\"header\":\"test1\",\"description\":\"\",\"userId\":\"\","+
+				"\"eventTime\":\"%[2]s\"},\"time\":\"%[2]s\"}\n\n"+
+				"id: 4\nevent: deleted\ndata: {\"revision\":4,\"type\":\"deleted\","+
+				"\"event\":{\"id\":\"%[1]s\",\"header\":\"test1\",\"description\":\"\",\"userId\":\"\","+
+				"\"eventTime\":\"%[2]s\"},\"time\":\"%[2]s\"}\n\n", testEventID, testTime.Format(time.RFC3339)),
+		},
+		{
+			name:        "changes of the window after the last event id",
+			path:        "/event/stream?from=2024-02-14&to=2024-02-14",
+			lastEventID: "7",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().WatchEvents(gomock.Any(), int64(7)).Return(changes(
+					models.Change{Revision: 8, Type: models.ChangeUpdated, Event: models.Event{
+						ID: testEventID, EventTime: testTime.AddDate(0, 0, 1),
+					}},
+					models.Change{Revision: 9, Type: models.ChangeUpdated, Event: models.Event{
+						ID: testEventID, EventTime: testTime,
+					}},
+				), nil)
+			},
+			expectedStatusCode: 200,
+			expectedBody: fmt.Sprintf("id: 9\nevent: updated\ndata: {\"revision\":9,\"type\":\"updated\","+
+				"\"event\":{\"id\":\"%s\",\"header\":\"\",\"description\":\"\",\"userId\":\"\","+
+				"\"eventTime\":\"%s\"},\"time\":\"0001-01-01T00:00:00Z\"}\n\n", testEventID, testTime.Format(time.RFC3339)),
+		},
+		{
+			name:               "invalid last event id",
+			path:               "/event/stream",
+			lastEventID:        "abc",
+			mockBehavior:       func(_ *mockservice.MockApplicationInterface) {},
+			expectedStatusCode: 400,
+			expectedBody: problemBody(400,
+				`invalid Last-Event-ID header: strconv.ParseInt: parsing "abc": invalid syntax`),
+		},
+		{
+			name:        "negative last event id",
+			path:        "/event/stream",
+			lastEventID: "-1",
+			mockBehavior: func(s *mockservice.MockApplicationInterface) {
+				s.EXPECT().WatchEvents(gomock.Any(), int64(-1)).
+					Return(nil, fmt.Errorf("%w: revision must not be negative", app.ErrInvalidArgument))
+			},
+			expectedStatusCode: 400,
+			expectedBody:       problemBody(400, "invalid argument: revision must not be negative"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			appInterface := mockservice.NewMockApplicationInterface(c)
+			testCase.mockBehavior(appInterface)
+			logg, err := logger.GetLogger("INFO")
+			require.NoError(t, err)
+			handler := NewHandler(logg, appInterface)
+			r := handler.InitRoutes()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, testCase.path, nil)
+			req.Header.Set(UserIDHeader, uuid.New().String())
+			if testCase.lastEventID != "" {
+				req.Header.Set(lastEventIDHeader, testCase.lastEventID)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestStreamEventsHeartbeat(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	appInterface := mockservice.NewMockApplicationInterface(c)
+	appInterface.EXPECT().WatchEvents(gomock.Any(), int64(0)).
+		DoAndReturn(func(ctx context.Context, _ int64) (<-chan models.Change, error) {
+			changes := make(chan models.Change)
+			go func() {
+				<-ctx.Done()
+				close(changes)
+			}()
+
+			return changes, nil
+		})
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	handler := NewHandler(logg, appInterface)
+	handler.heartbeat = 10 * time.Millisecond
+	r := handler.InitRoutes()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/event/stream", nil).WithContext(ctx)
+	req.Header.Set(UserIDHeader, uuid.New().String())
+
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	require.Positive(t, strings.Count(w.Body.String(), ": heartbeat\n\n"))
+}
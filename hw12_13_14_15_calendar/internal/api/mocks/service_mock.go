@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	app "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
 	models "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,6 +37,21 @@ func (m *MockApplicationInterface) EXPECT() *MockApplicationInterfaceMockRecorde
 	return m.recorder
 }
 
+// ApplyBatch mocks base method.
+func (m *MockApplicationInterface) ApplyBatch(ctx context.Context, items []models.BatchItem, atomic bool) ([]models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyBatch", ctx, items, atomic)
+	ret0, _ := ret[0].([]models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyBatch indicates an expected call of ApplyBatch.
+func (mr *MockApplicationInterfaceMockRecorder) ApplyBatch(ctx, items, atomic interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyBatch", reflect.TypeOf((*MockApplicationInterface)(nil).ApplyBatch), ctx, items, atomic)
+}
+
 // CreateEvent mocks base method.
 func (m *MockApplicationInterface) CreateEvent(ctx context.Context, eventDTO models.Event) (string, error) {
 	m.ctrl.T.Helper()
@@ -52,17 +68,62 @@ func (mr *MockApplicationInterfaceMockRecorder) CreateEvent(ctx, eventDTO interf
 }
 
 // DeleteEvent mocks base method.
-func (m *MockApplicationInterface) DeleteEvent(ctx context.Context, id string) error {
+func (m *MockApplicationInterface) DeleteEvent(ctx context.Context, id string, version int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteEvent", ctx, id)
+	ret := m.ctrl.Call(m, "DeleteEvent", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteEvent indicates an expected call of DeleteEvent.
-func (mr *MockApplicationInterfaceMockRecorder) DeleteEvent(ctx, id interface{}) *gomock.Call {
+func (mr *MockApplicationInterfaceMockRecorder) DeleteEvent(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEvent", reflect.TypeOf((*MockApplicationInterface)(nil).DeleteEvent), ctx, id, version)
+}
+
+// ExportEvents mocks base method.
+func (m *MockApplicationInterface) ExportEvents(ctx context.Context, start time.Time, amountDays int) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportEvents", ctx, start, amountDays)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportEvents indicates an expected call of ExportEvents.
+func (mr *MockApplicationInterfaceMockRecorder) ExportEvents(ctx, start, amountDays interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportEvents", reflect.TypeOf((*MockApplicationInterface)(nil).ExportEvents), ctx, start, amountDays)
+}
+
+// GetEvent mocks base method.
+func (m *MockApplicationInterface) GetEvent(ctx context.Context, id string) (models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvent", ctx, id)
+	ret0, _ := ret[0].(models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvent indicates an expected call of GetEvent.
+func (mr *MockApplicationInterfaceMockRecorder) GetEvent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvent", reflect.TypeOf((*MockApplicationInterface)(nil).GetEvent), ctx, id)
+}
+
+// GetEventHistory mocks base method.
+func (m *MockApplicationInterface) GetEventHistory(ctx context.Context, id string) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventHistory", ctx, id)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventHistory indicates an expected call of GetEventHistory.
+func (mr *MockApplicationInterfaceMockRecorder) GetEventHistory(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEvent", reflect.TypeOf((*MockApplicationInterface)(nil).DeleteEvent), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventHistory", reflect.TypeOf((*MockApplicationInterface)(nil).GetEventHistory), ctx, id)
 }
 
 // GetListEventsDuringDay mocks base method.
@@ -95,6 +156,126 @@ func (mr *MockApplicationInterfaceMockRecorder) GetListEventsDuringFewDays(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListEventsDuringFewDays", reflect.TypeOf((*MockApplicationInterface)(nil).GetListEventsDuringFewDays), ctx, start, amountDays)
 }
 
+// GetListEventsDuringMonth mocks base method.
+func (m *MockApplicationInterface) GetListEventsDuringMonth(ctx context.Context, day time.Time) ([]models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListEventsDuringMonth", ctx, day)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListEventsDuringMonth indicates an expected call of GetListEventsDuringMonth.
+func (mr *MockApplicationInterfaceMockRecorder) GetListEventsDuringMonth(ctx, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListEventsDuringMonth", reflect.TypeOf((*MockApplicationInterface)(nil).GetListEventsDuringMonth), ctx, day)
+}
+
+// GetListEventsDuringWeek mocks base method.
+func (m *MockApplicationInterface) GetListEventsDuringWeek(ctx context.Context, day time.Time) ([]models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListEventsDuringWeek", ctx, day)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListEventsDuringWeek indicates an expected call of GetListEventsDuringWeek.
+func (mr *MockApplicationInterfaceMockRecorder) GetListEventsDuringWeek(ctx, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListEventsDuringWeek", reflect.TypeOf((*MockApplicationInterface)(nil).GetListEventsDuringWeek), ctx, day)
+}
+
+// GetListEventsPage mocks base method.
+func (m *MockApplicationInterface) GetListEventsPage(ctx context.Context, start time.Time, amountDays, limit int, pageToken string) (app.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListEventsPage", ctx, start, amountDays, limit, pageToken)
+	ret0, _ := ret[0].(app.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListEventsPage indicates an expected call of GetListEventsPage.
+func (mr *MockApplicationInterfaceMockRecorder) GetListEventsPage(ctx, start, amountDays, limit, pageToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListEventsPage", reflect.TypeOf((*MockApplicationInterface)(nil).GetListEventsPage), ctx, start, amountDays, limit, pageToken)
+}
+
+// GetTrash mocks base method.
+func (m *MockApplicationInterface) GetTrash(ctx context.Context) ([]models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", ctx)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrash indicates an expected call of GetTrash.
+func (mr *MockApplicationInterfaceMockRecorder) GetTrash(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockApplicationInterface)(nil).GetTrash), ctx)
+}
+
+// ImportEvents mocks base method.
+func (m *MockApplicationInterface) ImportEvents(ctx context.Context, calendar []byte) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportEvents", ctx, calendar)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportEvents indicates an expected call of ImportEvents.
+func (mr *MockApplicationInterfaceMockRecorder) ImportEvents(ctx, calendar interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportEvents", reflect.TypeOf((*MockApplicationInterface)(nil).ImportEvents), ctx, calendar)
+}
+
+// PatchEvent mocks base method.
+func (m *MockApplicationInterface) PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchEvent", ctx, id, patch)
+	ret0, _ := ret[0].(models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchEvent indicates an expected call of PatchEvent.
+func (mr *MockApplicationInterfaceMockRecorder) PatchEvent(ctx, id, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchEvent", reflect.TypeOf((*MockApplicationInterface)(nil).PatchEvent), ctx, id, patch)
+}
+
+// RestoreEvent mocks base method.
+func (m *MockApplicationInterface) RestoreEvent(ctx context.Context, id string) (models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreEvent", ctx, id)
+	ret0, _ := ret[0].(models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreEvent indicates an expected call of RestoreEvent.
+func (mr *MockApplicationInterfaceMockRecorder) RestoreEvent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreEvent", reflect.TypeOf((*MockApplicationInterface)(nil).RestoreEvent), ctx, id)
+}
+
+// SearchEvents mocks base method.
+func (m *MockApplicationInterface) SearchEvents(ctx context.Context, query models.SearchQuery) ([]models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchEvents", ctx, query)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchEvents indicates an expected call of SearchEvents.
+func (mr *MockApplicationInterfaceMockRecorder) SearchEvents(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchEvents", reflect.TypeOf((*MockApplicationInterface)(nil).SearchEvents), ctx, query)
+}
+
 // UpdateEvent mocks base method.
 func (m *MockApplicationInterface) UpdateEvent(ctx context.Context, eventDTO models.Event) error {
 	m.ctrl.T.Helper()
@@ -108,3 +289,18 @@ func (mr *MockApplicationInterfaceMockRecorder) UpdateEvent(ctx, eventDTO interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEvent", reflect.TypeOf((*MockApplicationInterface)(nil).UpdateEvent), ctx, eventDTO)
 }
+
+// WatchEvents mocks base method.
+func (m *MockApplicationInterface) WatchEvents(ctx context.Context, afterRevision int64) (<-chan models.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchEvents", ctx, afterRevision)
+	ret0, _ := ret[0].(<-chan models.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchEvents indicates an expected call of WatchEvents.
+func (mr *MockApplicationInterfaceMockRecorder) WatchEvents(ctx, afterRevision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEvents", reflect.TypeOf((*MockApplicationInterface)(nil).WatchEvents), ctx, afterRevision)
+}
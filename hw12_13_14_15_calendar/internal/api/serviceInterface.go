@@ -5,6 +5,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
 )
 
@@ -12,7 +13,20 @@ import (
 type ApplicationInterface interface {
 	CreateEvent(ctx context.Context, eventDTO models.Event) (string, error)
 	UpdateEvent(ctx context.Context, eventDTO models.Event) error
-	DeleteEvent(ctx context.Context, id string) error
+	PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error)
+	DeleteEvent(ctx context.Context, id string, version int64) error
+	ApplyBatch(ctx context.Context, items []models.BatchItem, atomic bool) ([]models.BatchResult, error)
+	GetEvent(ctx context.Context, id string) (models.Event, error)
+	GetTrash(ctx context.Context) ([]models.Event, error)
+	RestoreEvent(ctx context.Context, id string) (models.Event, error)
+	GetEventHistory(ctx context.Context, id string) ([]models.AuditEntry, error)
+	WatchEvents(ctx context.Context, afterRevision int64) (<-chan models.Change, error)
 	GetListEventsDuringDay(ctx context.Context, day time.Time) ([]models.Event, error)
 	GetListEventsDuringFewDays(ctx context.Context, start time.Time, amountDays int) ([]models.Event, error)
+	GetListEventsPage(ctx context.Context, start time.Time, amountDays, limit int, pageToken string) (app.Page, error)
+	SearchEvents(ctx context.Context, query models.SearchQuery) ([]models.Event, error)
+	GetListEventsDuringWeek(ctx context.Context, day time.Time) ([]models.Event, error)
+	GetListEventsDuringMonth(ctx context.Context, day time.Time) ([]models.Event, error)
+	ExportEvents(ctx context.Context, start time.Time, amountDays int) ([]byte, error)
+	ImportEvents(ctx context.Context, calendar []byte) ([]string, error)
 }
@@ -2,15 +2,21 @@ package app
 
 //nolint:depguard
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/ical"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
 )
 
 type App struct {
-	logger  Logger
-	storage Storage
+	logger       Logger
+	storage      Storage
+	firstWeekday time.Weekday
+	feed         *changeFeed
 }
 
 type Logger interface {
@@ -22,34 +28,327 @@ type Logger interface {
 }
 
 type Storage interface {
+	// Mutations record their audit entries, made by NewAuditEntry, atomically with the change.
 	CreateEvent(ctx context.Context, eventDTO models.Event) (string, error)
 	UpdateEvent(ctx context.Context, eventDTO models.Event) error
-	DeleteEvent(ctx context.Context, id string) error
+	PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error)
+	DeleteEvent(ctx context.Context, id string, version int64) error
+	// ApplyBatch applies the items in order. An atomic batch stops at the first failed item and undoes the others.
+	ApplyBatch(ctx context.Context, items []models.BatchItem, atomic bool) ([]models.BatchResult, error)
+	GetEvent(ctx context.Context, id string) (models.Event, error)
 	GetListEventsDuringDay(ctx context.Context, day time.Time) ([]models.Event, error)
 	GetListEventsDuringFewDays(ctx context.Context, start time.Time, amountDays int) ([]models.Event, error)
+	GetListEventsInRange(ctx context.Context, from, to time.Time) ([]models.Event, error)
+	GetListEventsPage(ctx context.Context, from, to time.Time, after *models.Cursor, limit int) ([]models.Event, error)
+	SearchEvents(ctx context.Context, query models.SearchQuery) ([]models.Event, error)
+	GetListDeletedEvents(ctx context.Context) ([]models.Event, error)
+	RestoreEvent(ctx context.Context, id string) (models.Event, error)
+	GetEventHistory(ctx context.Context, eventID string) ([]models.AuditEntry, error)
+	// GetChanges returns audit entries made after the revision, which is the id of an audit entry, in its order.
+	GetChanges(ctx context.Context, afterRevision int64, limit int) ([]models.AuditEntry, error)
+	GetRevision(ctx context.Context) (int64, error)
+	// WatchRevisions reports revisions of new audit entries, also those of other instances,
+	// until ctx is done or the watching is interrupted.
+	WatchRevisions(ctx context.Context) (<-chan int64, error)
 	Close()
 }
 
-func New(logger Logger, storage Storage) *App {
-	return &App{logger: logger, storage: storage}
+// New starts watching changes of the storage for WatchEvents, Close stops it.
+func New(logger Logger, storage Storage, firstWeekday time.Weekday) *App {
+	return &App{
+		logger: logger, storage: storage, firstWeekday: firstWeekday, feed: startChangeFeed(storage, logger),
+	}
+}
+
+// Close stops watching changes of the storage and ends streams of WatchEvents, the storage is left open.
+func (a *App) Close() {
+	a.feed.close()
 }
 
 func (a *App) CreateEvent(ctx context.Context, dto models.Event) (string, error) {
+	userID, err := a.userID(ctx)
+	if err != nil {
+		return "", err
+	}
+	dto.UserID = userID
+	dto = dto.WithReminder()
+
+	if err := a.validate(dto); err != nil {
+		return "", err
+	}
+
 	return a.storage.CreateEvent(ctx, dto)
 }
 
 func (a *App) UpdateEvent(ctx context.Context, eventDTO models.Event) error {
+	userID, err := a.userID(ctx)
+	if err != nil {
+		return err
+	}
+	eventDTO.UserID = userID
+	eventDTO = eventDTO.WithReminder()
+
+	if err := a.validate(eventDTO); err != nil {
+		return err
+	}
+
 	return a.storage.UpdateEvent(ctx, eventDTO)
 }
 
-func (a *App) DeleteEvent(ctx context.Context, id string) error {
-	return a.storage.DeleteEvent(ctx, id)
+// patchAttempts limits how many times a patch without an expected version is validated again
+// after the event was changed concurrently.
+const patchAttempts = 3
+
+// PatchEvent changes only the fields listed in the patch and returns the updated event.
+func (a *App) PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return models.Event{}, err
+	}
+
+	if err := patch.Validate(); err != nil {
+		a.logger.Error("invalid patch of event", map[string]interface{}{"error": err, "id": id})
+		return models.Event{}, fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+
+	// Rules like the order of times involve fields left as they are, so the patched event is validated.
+	// It is stored only if the event is still of the validated version, without an expected version
+	// a concurrent change makes the patch read and validate the event again.
+	for attempt := 1; ; attempt++ {
+		current, err := a.storage.GetEvent(ctx, id)
+		if err != nil {
+			// A missing event is reported by the storage.
+			return a.storage.PatchEvent(ctx, id, patch)
+		}
+
+		checked := patch.WithReminder(current)
+		if err := a.validate(checked.Apply(current)); err != nil {
+			return models.Event{}, err
+		}
+
+		if patch.Event.Version == 0 {
+			checked.Event.Version = current.Version
+		}
+
+		event, err := a.storage.PatchEvent(ctx, id, checked)
+		if patch.Event.Version != 0 || !errors.Is(err, ErrVersionMismatch) {
+			return event, err
+		}
+
+		if attempt == patchAttempts {
+			a.logger.Error("event kept changing while it was patched", map[string]interface{}{"id": id})
+			return models.Event{}, fmt.Errorf("%w: event kept changing while it was patched", ErrAborted)
+		}
+	}
+}
+
+// DeleteEvent removes the event, a non-zero version must match the current version of the event.
+func (a *App) DeleteEvent(ctx context.Context, id string, version int64) error {
+	if _, err := a.userID(ctx); err != nil {
+		return err
+	}
+
+	if version < 0 {
+		return fmt.Errorf("%w: version must not be negative", ErrInvalidArgument)
+	}
+
+	return a.storage.DeleteEvent(ctx, id, version)
+}
+
+// GetTrash returns deleted events of the caller which are not purged yet.
+func (a *App) GetTrash(ctx context.Context) ([]models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	return a.storage.GetListDeletedEvents(ctx)
+}
+
+// RestoreEvent takes the event out of the trash.
+func (a *App) RestoreEvent(ctx context.Context, id string) (models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return models.Event{}, err
+	}
+
+	return a.storage.RestoreEvent(ctx, id)
+}
+
+func (a *App) GetEvent(ctx context.Context, id string) (models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return models.Event{}, err
+	}
+
+	return a.storage.GetEvent(ctx, id)
 }
 
 func (a *App) GetListEventsDuringDay(ctx context.Context, day time.Time) ([]models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
 	return a.storage.GetListEventsDuringDay(ctx, day)
 }
 
 func (a *App) GetListEventsDuringFewDays(ctx context.Context, start time.Time, amountDays int) ([]models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
 	return a.storage.GetListEventsDuringFewDays(ctx, start, amountDays)
 }
+
+// GetListEventsPage returns a page of events of amountDays days from start, a single day if amountDays is 0.
+func (a *App) GetListEventsPage(
+	ctx context.Context, start time.Time, amountDays, limit int, pageToken string,
+) (Page, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return Page{}, err
+	}
+
+	limit, err := pageSize(limit)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var after *models.Cursor
+	if pageToken != "" {
+		cursor, err := DecodePageToken(pageToken)
+		if err != nil {
+			a.logger.Error("invalid page token", map[string]interface{}{"error": err})
+			return Page{}, err
+		}
+		after = &cursor
+	}
+
+	if amountDays == 0 {
+		amountDays = 1
+	}
+	from, to := DaysBounds(start, amountDays)
+
+	// One extra event tells whether there is a next page.
+	events, err := a.storage.GetListEventsPage(ctx, from, to, after, limit+1)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextPageToken = EncodePageToken(models.CursorOf(page.Events[limit-1]))
+	}
+
+	return page, nil
+}
+
+// SearchEvents finds events containing every word of the query text, ordered by start time.
+func (a *App) SearchEvents(ctx context.Context, query models.SearchQuery) ([]models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(models.SearchTerms(query.Text)) == 0 {
+		a.logger.Error("empty search query", map[string]interface{}{"query": query.Text})
+		return nil, fmt.Errorf("%w: search query must contain words", ErrInvalidArgument)
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		a.logger.Error("invalid search range", map[string]interface{}{"from": query.From, "to": query.To})
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidArgument)
+	}
+
+	limit, err := pageSize(query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = limit
+
+	return a.storage.SearchEvents(ctx, query)
+}
+
+// GetListEventsDuringWeek returns events of the week containing the day, weeks start on the configured weekday.
+func (a *App) GetListEventsDuringWeek(ctx context.Context, day time.Time) ([]models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	from, to := WeekBounds(day, a.firstWeekday)
+
+	return a.storage.GetListEventsInRange(ctx, from, to)
+}
+
+// GetListEventsDuringMonth returns events of the calendar month containing the day.
+func (a *App) GetListEventsDuringMonth(ctx context.Context, day time.Time) ([]models.Event, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	from, to := MonthBounds(day)
+
+	return a.storage.GetListEventsInRange(ctx, from, to)
+}
+
+func (a *App) ExportEvents(ctx context.Context, start time.Time, amountDays int) ([]byte, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	var events []models.Event
+	var err error
+	switch amountDays {
+	case 0:
+		events, err = a.storage.GetListEventsDuringDay(ctx, start)
+	default:
+		events, err = a.storage.GetListEventsDuringFewDays(ctx, start, amountDays)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ical.Encode(events, time.Now()), nil
+}
+
+func (a *App) ImportEvents(ctx context.Context, calendar []byte) ([]string, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	events, err := ical.Decode(bytes.NewReader(calendar))
+	if err != nil {
+		a.logger.Error("error while decoding calendar", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("%w: error while decoding calendar: %w", ErrInvalidArgument, err)
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		id, err := a.CreateEvent(ctx, event)
+		if err != nil {
+			return ids, fmt.Errorf("error while importing event %q: %w", event.Header, err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	a.logger.Info("events were imported", map[string]interface{}{"count": len(ids)})
+
+	return ids, nil
+}
+
+// validate checks the event against the rules of models.Event before any storage gets it.
+func (a *App) validate(event models.Event) error {
+	if err := event.Validate(); err != nil {
+		a.logger.Error("invalid event", map[string]interface{}{"error": err, "id": event.ID})
+		return fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+	}
+
+	return nil
+}
+
+func (a *App) userID(ctx context.Context) (string, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		a.logger.Error("user id is not specified in request context", nil)
+		return "", ErrUserNotSpecified
+	}
+
+	return userID, nil
+}
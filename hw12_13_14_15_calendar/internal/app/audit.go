@@ -0,0 +1,54 @@
+package app
+
+//nolint:depguard
+import (
+	"context"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+// GetEventHistory returns changes of the event in the order they were made.
+func (a *App) GetEventHistory(ctx context.Context, id string) ([]models.AuditEntry, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	entries, err := a.storage.GetEventHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Events created before the audit log have no history, others are hidden by user scoping.
+	if len(entries) == 0 {
+		if _, err := a.storage.GetEvent(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// NewAuditEntry describes the change of the event made by the caller from the context. Storages record it
+// together with the change, before is nil for created and restored events and after is nil for deleted ones.
+func NewAuditEntry(ctx context.Context, action models.AuditAction, before, after *models.Event) models.AuditEntry {
+	actor, _ := UserIDFromContext(ctx)
+	entry := models.AuditEntry{
+		Actor:  actor,
+		Action: action,
+		Before: before,
+		After:  after,
+		Time:   time.Now().UTC(),
+	}
+
+	switch {
+	case after != nil:
+		entry.EventID, entry.UserID = after.ID, after.UserID
+	case before != nil:
+		entry.EventID, entry.UserID = before.ID, before.UserID
+	default:
+		entry.UserID = actor
+	}
+
+	return entry
+}
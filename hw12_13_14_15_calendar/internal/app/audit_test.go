@@ -0,0 +1,69 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHistory(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	calendar := app.New(logg, memorystorage.New(logg), time.Monday)
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+
+	id, err := calendar.CreateEvent(ctx, models.Event{Header: "meeting", EventTime: start})
+	require.NoError(t, err)
+	require.NoError(t, calendar.UpdateEvent(ctx, models.Event{ID: id, Header: "planning", EventTime: start}))
+	_, err = calendar.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{Description: "sprint"}, Fields: []string{"description"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, calendar.DeleteEvent(ctx, id, 0))
+	_, err = calendar.RestoreEvent(ctx, id)
+	require.NoError(t, err)
+
+	// Failed changes are not recorded.
+	require.ErrorIs(t, calendar.DeleteEvent(ctx, id, 1), app.ErrVersionMismatch)
+
+	history, err := calendar.GetEventHistory(ctx, id)
+	require.NoError(t, err)
+
+	actions := make([]models.AuditAction, 0, len(history))
+	for _, entry := range history {
+		require.Equal(t, id, entry.EventID)
+		require.Equal(t, "user", entry.UserID)
+		require.Equal(t, "user", entry.Actor)
+		require.False(t, entry.Time.IsZero())
+		actions = append(actions, entry.Action)
+	}
+	require.Equal(t, []models.AuditAction{
+		models.AuditCreate, models.AuditUpdate, models.AuditUpdate, models.AuditDelete, models.AuditRestore,
+	}, actions)
+
+	require.Nil(t, history[0].Before)
+	require.Equal(t, "meeting", history[0].After.Header)
+	require.Equal(t, "meeting", history[1].Before.Header)
+	require.Equal(t, "planning", history[1].After.Header)
+	require.Equal(t, "", history[2].Before.Description)
+	require.Equal(t, "sprint", history[2].After.Description)
+	require.Equal(t, int64(3), history[3].Before.Version)
+	require.Nil(t, history[3].After)
+	require.Nil(t, history[4].Before)
+	require.Equal(t, int64(5), history[4].After.Version)
+
+	_, err = calendar.GetEventHistory(app.ContextWithUserID(context.Background(), "another user"), id)
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+	_, err = calendar.GetEventHistory(ctx, uuid.New().String())
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+	_, err = calendar.GetEventHistory(context.Background(), id)
+	require.ErrorIs(t, err, app.ErrUserNotSpecified)
+}
@@ -0,0 +1,91 @@
+package app
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+const MaxBatchSize = 5000
+
+// ApplyBatch creates, updates and deletes events of the user and returns the result of every item.
+// Failed items do not stop the batch unless it is atomic, then every other item is rolled back
+// and reported as ErrBatchAborted. Errors of the items are returned in the results only.
+func (a *App) ApplyBatch(ctx context.Context, items []models.BatchItem, atomic bool) ([]models.BatchResult, error) {
+	userID, err := a.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: batch is empty", ErrInvalidArgument)
+	}
+
+	if len(items) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: batch must not contain more than %d items", ErrInvalidArgument, MaxBatchSize)
+	}
+
+	results := make([]models.BatchResult, len(items))
+	valid := make([]models.BatchItem, 0, len(items))
+	positions := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].ID = item.Event.ID
+		item.Event = item.Event.WithReminder()
+		if err := item.Validate(); err != nil {
+			a.logger.Error("invalid batch item", map[string]interface{}{"error": err, "item": i})
+			results[i].Err = fmt.Errorf("%w: %w", ErrInvalidArgument, err)
+			continue
+		}
+
+		item.Event.UserID = userID
+		valid = append(valid, item)
+		positions = append(positions, i)
+	}
+
+	if atomic && len(valid) < len(items) {
+		return abortBatch(items, results), nil
+	}
+
+	applied, err := a.storage.ApplyBatch(ctx, valid, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range applied {
+		if result.ID == "" {
+			result.ID = valid[i].Event.ID
+		}
+		results[positions[i]] = result
+	}
+
+	if atomic && failed(results) {
+		return abortBatch(items, results), nil
+	}
+
+	return results, nil
+}
+
+// abortBatch marks every item of the rolled back batch, which has not failed itself, as aborted.
+func abortBatch(items []models.BatchItem, results []models.BatchResult) []models.BatchResult {
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+
+		results[i] = models.BatchResult{ID: items[i].Event.ID, Err: ErrBatchAborted}
+	}
+
+	return results
+}
+
+func failed(results []models.BatchResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return true
+		}
+	}
+
+	return false
+}
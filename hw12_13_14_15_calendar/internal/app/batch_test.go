@@ -0,0 +1,83 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBatch(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	calendar := app.New(logg, memorystorage.New(logg), time.Monday)
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+
+	id, err := calendar.CreateEvent(ctx, models.Event{Header: "existing", EventTime: start})
+	require.NoError(t, err)
+	items := []models.BatchItem{
+		{Operation: models.BatchCreate, Event: models.Event{Header: "created", EventTime: start}},
+		{Operation: models.BatchUpdate, Event: models.Event{ID: id, Header: "updated", EventTime: start}},
+		{Operation: models.BatchCreate, Event: models.Event{Header: "invalid", EventTime: start, TimeZone: "Mars/Base"}},
+		{Operation: "move", Event: models.Event{ID: id}},
+	}
+
+	t.Run("atomic batch with invalid items is not applied", func(t *testing.T) {
+		results, err := calendar.ApplyBatch(ctx, items, true)
+		require.NoError(t, err)
+		require.ErrorIs(t, results[0].Err, app.ErrBatchAborted)
+		require.Empty(t, results[0].ID)
+		require.ErrorIs(t, results[1].Err, app.ErrBatchAborted)
+		require.Equal(t, id, results[1].ID)
+		require.ErrorIs(t, results[2].Err, app.ErrInvalidArgument)
+		require.ErrorIs(t, results[3].Err, app.ErrInvalidArgument)
+
+		event, err := calendar.GetEvent(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, "existing", event.Header)
+	})
+
+	t.Run("failed item of atomic batch aborts the others", func(t *testing.T) {
+		results, err := calendar.ApplyBatch(ctx, []models.BatchItem{
+			items[0],
+			{Operation: models.BatchDelete, Event: models.Event{ID: id, Version: 5}},
+		}, true)
+		require.NoError(t, err)
+		require.ErrorIs(t, results[0].Err, app.ErrBatchAborted)
+		require.Empty(t, results[0].ID)
+		require.ErrorIs(t, results[1].Err, app.ErrVersionMismatch)
+	})
+
+	t.Run("valid items are applied and audited", func(t *testing.T) {
+		results, err := calendar.ApplyBatch(ctx, items, false)
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		require.NotEmpty(t, results[0].ID)
+		require.NoError(t, results[1].Err)
+		require.Equal(t, int64(2), results[1].Event.Version)
+		require.ErrorIs(t, results[2].Err, app.ErrInvalidArgument)
+		require.ErrorIs(t, results[3].Err, app.ErrInvalidArgument)
+
+		created, err := calendar.GetEvent(ctx, results[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, "user", created.UserID)
+
+		history, err := calendar.GetEventHistory(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, models.AuditUpdate, history[1].Action)
+		require.Equal(t, "existing", history[1].Before.Header)
+		require.Equal(t, "updated", history[1].After.Header)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		_, err := calendar.ApplyBatch(ctx, nil, false)
+		require.ErrorIs(t, err, app.ErrInvalidArgument)
+	})
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors of the domain. Storages wrap them with details, transports map them to status codes.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrConflict        = errors.New("conflict")
+	ErrForbidden       = errors.New("forbidden")
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPreconditionFailed is returned when a condition of the request, like the expected version, does not hold.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrAborted is returned for a change that was undone because another change of the same request failed.
+	ErrAborted = errors.New("aborted")
+)
+
+var (
+	ErrEventNotFound    = fmt.Errorf("%w: event with such an id does not exist", ErrNotFound)
+	ErrEventForbidden   = fmt.Errorf("%w: event belongs to another user", ErrForbidden)
+	ErrUserNotSpecified = fmt.Errorf("%w: user id is not specified", ErrUnauthenticated)
+	// ErrDateBusy is returned when an event overlaps another event of the same user.
+	// Only single events with a finish time occupy a time slot.
+	ErrDateBusy        = fmt.Errorf("%w: the time slot is already taken by another event", ErrConflict)
+	ErrEventNotDeleted = fmt.Errorf("%w: event is not in the trash", ErrConflict)
+	// ErrVersionMismatch is returned when the event was changed since the caller read it.
+	ErrVersionMismatch = fmt.Errorf("%w: event version does not match the expected one", ErrPreconditionFailed)
+	ErrBatchAborted    = fmt.Errorf("%w: batch was rolled back because another item failed", ErrAborted)
+)
@@ -0,0 +1,22 @@
+package app
+
+import "context"
+
+type KeyUserID string
+
+const userIDKey = KeyUserID("userID")
+
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the identity of the caller. Storages scope their queries by it
+// whenever it is present; calls without identity come from internal processes like the scheduler.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	if !ok || userID == "" {
+		return "", false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,61 @@
+package app
+
+//nolint:depguard
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+type Page struct {
+	Events        []models.Event
+	NextPageToken string
+}
+
+// pageSize applies the default and the maximum to the requested number of events.
+func pageSize(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, fmt.Errorf("%w: limit must not be negative", ErrInvalidArgument)
+	case limit == 0:
+		return DefaultPageSize, nil
+	case limit > MaxPageSize:
+		return MaxPageSize, nil
+	default:
+		return limit, nil
+	}
+}
+
+// EncodePageToken makes an opaque token of the cursor.
+func EncodePageToken(cursor models.Cursor) string {
+	raw := fmt.Sprintf("%d|%s", cursor.EventTime.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodePageToken(token string) (models.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return models.Cursor{}, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return models.Cursor{}, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return models.Cursor{}, fmt.Errorf("%w: invalid page token", ErrInvalidArgument)
+	}
+
+	return models.Cursor{EventTime: time.Unix(0, unixNano).UTC(), ID: id}, nil
+}
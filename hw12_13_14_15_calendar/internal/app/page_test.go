@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageToken(t *testing.T) {
+	cursor := models.Cursor{
+		EventTime: time.Date(2024, time.January, 10, 9, 0, 0, 123, time.UTC),
+		ID:        "0f8fad5b-d9cb-469f-a165-70867728950e",
+	}
+
+	decoded, err := DecodePageToken(EncodePageToken(cursor))
+	require.NoError(t, err)
+	require.Equal(t, cursor, decoded)
+
+	for _, token := range []string{"not base64!", "bm8gc2VwYXJhdG9y", "eHx5"} {
+		_, err = DecodePageToken(token)
+		require.ErrorIs(t, err, ErrInvalidArgument, token)
+	}
+}
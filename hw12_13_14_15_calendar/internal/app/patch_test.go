@@ -0,0 +1,75 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// racingStorage applies the concurrent patch right before the first patch of the App is stored.
+type racingStorage struct {
+	*memorystorage.Storage
+	concurrent *models.EventPatch
+}
+
+func (s *racingStorage) PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error) {
+	if s.concurrent != nil {
+		concurrent := *s.concurrent
+		s.concurrent = nil
+		if _, err := s.Storage.PatchEvent(ctx, id, concurrent); err != nil {
+			return models.Event{}, err
+		}
+	}
+
+	return s.Storage.PatchEvent(ctx, id, patch)
+}
+
+func TestPatchEventConcurrently(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := &racingStorage{Storage: memorystorage.New(logg)}
+	calendar := app.New(logg, storage, time.Monday)
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+
+	id, err := calendar.CreateEvent(ctx, models.Event{Header: "meeting", EventTime: start, FinishEventTime: &finish})
+	require.NoError(t, err)
+
+	// A concurrent change of another field is kept, the patch is applied on top of it.
+	storage.concurrent = &models.EventPatch{Event: models.Event{Description: "agenda"}, Fields: []string{"description"}}
+	event, err := calendar.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{Header: "standup"}, Fields: []string{"header"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "standup", event.Header)
+	require.Equal(t, "agenda", event.Description)
+
+	// The patch is validated against the concurrently changed event, not against the one it was read as.
+	earlyFinish := start.Add(15 * time.Minute)
+	storage.concurrent = &models.EventPatch{
+		Event: models.Event{FinishEventTime: &earlyFinish}, Fields: []string{"finishEventTime"},
+	}
+	_, err = calendar.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{EventTime: start.Add(30 * time.Minute)}, Fields: []string{"eventTime"},
+	})
+	require.ErrorIs(t, err, app.ErrInvalidArgument)
+
+	event, err = storage.GetEvent(ctx, id)
+	require.NoError(t, err)
+	require.True(t, start.Equal(event.EventTime), "event time %v", event.EventTime)
+	require.True(t, earlyFinish.Equal(*event.FinishEventTime), "finish %v", event.FinishEventTime)
+
+	// An expected version is not taken over by a newer one.
+	storage.concurrent = &models.EventPatch{Event: models.Event{Description: "notes"}, Fields: []string{"description"}}
+	_, err = calendar.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{Header: "retro", Version: event.Version}, Fields: []string{"header"},
+	})
+	require.ErrorIs(t, err, app.ErrVersionMismatch)
+}
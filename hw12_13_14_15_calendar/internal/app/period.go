@@ -0,0 +1,57 @@
+package app
+
+//nolint:depguard
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DaysBounds returns the half-open interval from the midnight of the day to the midnight amountDays later,
+// both in the location of the day.
+func DaysBounds(day time.Time, amountDays int) (time.Time, time.Time) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+
+	return start, time.Date(start.Year(), start.Month(), start.Day()+amountDays, 0, 0, 0, 0, day.Location())
+}
+
+// WeekBounds returns the half-open interval of the calendar week containing the day. Boundaries are midnights
+// in the location of the day, so a week spanning a DST transition lasts 167 or 169 hours.
+func WeekBounds(day time.Time, firstWeekday time.Weekday) (time.Time, time.Time) {
+	offset := (int(day.Weekday()) - int(firstWeekday) + 7) % 7
+	start := time.Date(day.Year(), day.Month(), day.Day()-offset, 0, 0, 0, 0, day.Location())
+
+	return start, time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, day.Location())
+}
+
+// MonthBounds returns the half-open interval of the calendar month containing the day.
+func MonthBounds(day time.Time) (time.Time, time.Time) {
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+
+	return start, time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, day.Location())
+}
+
+// ISOWeekStart returns the Monday midnight of the ISO 8601 week.
+func ISOWeekStart(year, week int, loc *time.Location) (time.Time, error) {
+	// The 4th of January always belongs to the first ISO week.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	offset := (int(jan4.Weekday()) + 6) % 7
+	start := time.Date(year, time.January, 4-offset+(week-1)*7, 0, 0, 0, 0, loc)
+
+	if isoYear, isoWeek := start.ISOWeek(); week < 1 || isoYear != year || isoWeek != week {
+		return time.Time{}, fmt.Errorf("%w: week %d does not exist in %d", ErrInvalidArgument, week, year)
+	}
+
+	return start, nil
+}
+
+// ParseWeekday converts an English weekday name like "monday" or "Sun" to time.Weekday.
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) || strings.EqualFold(name, day.String()[:3]) {
+			return day, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("%w: unknown weekday %q", ErrInvalidArgument, name)
+}
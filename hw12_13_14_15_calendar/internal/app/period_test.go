@@ -0,0 +1,156 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeekBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		day            time.Time
+		firstWeekday   time.Weekday
+		expectedStart  time.Time
+		expectedLength time.Duration
+	}{
+		{
+			name:           "monday first",
+			day:            time.Date(2024, time.January, 10, 15, 0, 0, 0, time.UTC),
+			firstWeekday:   time.Monday,
+			expectedStart:  time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+			expectedLength: 7 * 24 * time.Hour,
+		},
+		{
+			name:           "sunday first",
+			day:            time.Date(2024, time.January, 10, 15, 0, 0, 0, time.UTC),
+			firstWeekday:   time.Sunday,
+			expectedStart:  time.Date(2024, time.January, 7, 0, 0, 0, 0, time.UTC),
+			expectedLength: 7 * 24 * time.Hour,
+		},
+		{
+			name:           "day is first weekday",
+			day:            time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+			firstWeekday:   time.Monday,
+			expectedStart:  time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+			expectedLength: 7 * 24 * time.Hour,
+		},
+		{
+			name:           "across years",
+			day:            time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			firstWeekday:   time.Monday,
+			expectedStart:  time.Date(2024, time.December, 30, 0, 0, 0, 0, time.UTC),
+			expectedLength: 7 * 24 * time.Hour,
+		},
+		{
+			name:           "spring forward",
+			day:            time.Date(2024, time.March, 31, 12, 0, 0, 0, berlin),
+			firstWeekday:   time.Monday,
+			expectedStart:  time.Date(2024, time.March, 25, 0, 0, 0, 0, berlin),
+			expectedLength: 7*24*time.Hour - time.Hour,
+		},
+		{
+			name:           "fall back",
+			day:            time.Date(2024, time.October, 27, 12, 0, 0, 0, berlin),
+			firstWeekday:   time.Monday,
+			expectedStart:  time.Date(2024, time.October, 21, 0, 0, 0, 0, berlin),
+			expectedLength: 7*24*time.Hour + time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, finish := WeekBounds(test.day, test.firstWeekday)
+			require.Equal(t, test.expectedStart, start)
+			require.Equal(t, test.expectedLength, finish.Sub(start))
+			require.Equal(t, test.firstWeekday, finish.Weekday())
+			require.Zero(t, finish.Hour())
+		})
+	}
+}
+
+func TestMonthBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		day            time.Time
+		expectedStart  time.Time
+		expectedFinish time.Time
+	}{
+		{
+			name:           "leap february",
+			day:            time.Date(2024, time.February, 29, 23, 59, 0, 0, time.UTC),
+			expectedStart:  time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			expectedFinish: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "december",
+			day:            time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC),
+			expectedStart:  time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			expectedFinish: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "fall back",
+			day:            time.Date(2024, time.October, 1, 0, 0, 0, 0, berlin),
+			expectedStart:  time.Date(2024, time.October, 1, 0, 0, 0, 0, berlin),
+			expectedFinish: time.Date(2024, time.November, 1, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, finish := MonthBounds(test.day)
+			require.Equal(t, test.expectedStart, start)
+			require.Equal(t, test.expectedFinish, finish)
+		})
+	}
+
+	start, finish := MonthBounds(time.Date(2024, time.October, 15, 0, 0, 0, 0, berlin))
+	require.Equal(t, 31*24*time.Hour+time.Hour, finish.Sub(start))
+}
+
+func TestISOWeekStart(t *testing.T) {
+	tests := []struct {
+		year          int
+		week          int
+		expected      time.Time
+		expectedError bool
+	}{
+		{year: 2024, week: 1, expected: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{year: 2021, week: 1, expected: time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)},
+		{year: 2020, week: 53, expected: time.Date(2020, time.December, 28, 0, 0, 0, 0, time.UTC)},
+		{year: 2025, week: 1, expected: time.Date(2024, time.December, 30, 0, 0, 0, 0, time.UTC)},
+		{year: 2021, week: 53, expectedError: true},
+		{year: 2024, week: 0, expectedError: true},
+	}
+
+	for _, test := range tests {
+		start, err := ISOWeekStart(test.year, test.week, time.UTC)
+		if test.expectedError {
+			require.ErrorIs(t, err, ErrInvalidArgument)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, test.expected, start)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	day, err := ParseWeekday("monday")
+	require.NoError(t, err)
+	require.Equal(t, time.Monday, day)
+
+	day, err = ParseWeekday("Sun")
+	require.NoError(t, err)
+	require.Equal(t, time.Sunday, day)
+
+	_, err = ParseWeekday("someday")
+	require.ErrorIs(t, err, ErrInvalidArgument)
+}
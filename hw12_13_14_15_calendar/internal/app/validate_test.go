@@ -0,0 +1,70 @@
+package app_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	calendar := app.New(logg, memorystorage.New(logg), time.Monday)
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	earlier, later := start.Add(-time.Hour), start.Add(time.Hour)
+
+	fields := func(err error) []string {
+		require.ErrorIs(t, err, app.ErrInvalidArgument)
+
+		var validationErrors validator.ValidationErrors
+		require.ErrorAs(t, err, &validationErrors)
+		names := make([]string, 0, len(validationErrors))
+		for _, validationErr := range validationErrors {
+			names = append(names, validationErr.Field)
+		}
+
+		return names
+	}
+
+	_, err = calendar.CreateEvent(ctx, models.Event{
+		Header:           strings.Repeat("a", 256),
+		EventTime:        start,
+		FinishEventTime:  &earlier,
+		NotificationTime: &later,
+		TimeZone:         "Mars/Olympus",
+	})
+	require.Equal(t, []string{"header", "finishEventTime", "notificationTime", "timeZone"}, fields(err))
+
+	id, err := calendar.CreateEvent(ctx, models.Event{
+		Header: strings.Repeat("я", 255), EventTime: start, FinishEventTime: &later, NotificationTime: &earlier,
+	})
+	require.NoError(t, err)
+
+	err = calendar.UpdateEvent(ctx, models.Event{ID: id, Header: "meeting", EventTime: start, FinishEventTime: &start})
+	require.Equal(t, []string{"finishEventTime"}, fields(err))
+
+	// Moving the start alone breaks the order of the times left as they are.
+	_, err = calendar.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{EventTime: later.Add(time.Hour)}, Fields: []string{"eventTime"},
+	})
+	require.Equal(t, []string{"finishEventTime"}, fields(err))
+
+	results, err := calendar.ApplyBatch(ctx, []models.BatchItem{
+		{Operation: models.BatchUpdate, Event: models.Event{ID: id, EventTime: start, NotificationTime: &later}},
+	}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"notificationTime"}, fields(results[0].Err))
+
+	event, err := calendar.GetEvent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), event.Version)
+}
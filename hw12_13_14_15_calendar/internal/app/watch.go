@@ -0,0 +1,174 @@
+package app
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+const (
+	watchPageSize     = 100
+	feedRetryInterval = 5 * time.Second
+)
+
+// changeFeed fans out revisions reported by the storage to the watchers of the process,
+// so the storage is watched once however many watchers there are.
+type changeFeed struct {
+	mu       sync.Mutex
+	watchers map[chan struct{}]struct{}
+	cancel   context.CancelFunc
+	// done is closed when the feed stops watching the storage.
+	done chan struct{}
+}
+
+// startChangeFeed watches the storage until the feed is closed. The storage is watched by the time it returns,
+// so revisions of changes made after that are not missed.
+func startChangeFeed(storage Storage, logger Logger) *changeFeed {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &changeFeed{watchers: make(map[chan struct{}]struct{}), cancel: cancel, done: make(chan struct{})}
+	revisions, err := storage.WatchRevisions(ctx)
+	if err != nil {
+		logger.Error("error while watching changes", map[string]interface{}{"error": err})
+	}
+	go f.run(ctx, storage, logger, revisions)
+
+	return f
+}
+
+// close stops watching the storage, watchers of the feed stop as well.
+func (f *changeFeed) close() {
+	f.cancel()
+	<-f.done
+}
+
+// WatchEvents streams changes of events of the user made after the revision, zero means the current one.
+// The channel is closed when ctx is done, the App is closed or the changes can not be read, the caller resumes
+// with the revision of the last received change then.
+func (a *App) WatchEvents(ctx context.Context, afterRevision int64) (<-chan models.Change, error) {
+	if _, err := a.userID(ctx); err != nil {
+		return nil, err
+	}
+
+	if afterRevision < 0 {
+		return nil, fmt.Errorf("%w: revision must not be negative", ErrInvalidArgument)
+	}
+
+	// The watcher subscribes before the changes are read, so a change made in between either wakes it up
+	// or is read by watch before it waits.
+	wake := a.feed.subscribe()
+	if afterRevision == 0 {
+		revision, err := a.storage.GetRevision(ctx)
+		if err != nil {
+			a.feed.unsubscribe(wake)
+			return nil, err
+		}
+		afterRevision = revision
+	}
+
+	changes := make(chan models.Change)
+	go a.watch(ctx, afterRevision, wake, changes)
+
+	return changes, nil
+}
+
+func (a *App) watch(ctx context.Context, revision int64, wake chan struct{}, changes chan<- models.Change) {
+	defer close(changes)
+	defer a.feed.unsubscribe(wake)
+
+	for {
+		entries, err := a.storage.GetChanges(ctx, revision, watchPageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				a.logger.Error("error while reading changes", map[string]interface{}{"error": err, "revision": revision})
+			}
+			return
+		}
+
+		for _, entry := range entries {
+			select {
+			case changes <- models.ChangeOf(entry):
+				revision = entry.ID
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if len(entries) == watchPageSize {
+			continue
+		}
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return
+		case <-a.feed.done:
+			return
+		}
+	}
+}
+
+// subscribe registers a watcher, it is woken up by every revision reported after that.
+func (f *changeFeed) subscribe() chan struct{} {
+	wake := make(chan struct{}, 1)
+	f.mu.Lock()
+	f.watchers[wake] = struct{}{}
+	f.mu.Unlock()
+
+	return wake
+}
+
+func (f *changeFeed) unsubscribe(wake chan struct{}) {
+	f.mu.Lock()
+	delete(f.watchers, wake)
+	f.mu.Unlock()
+}
+
+// broadcast wakes up every watcher, a watcher which is already awake is not blocked on.
+func (f *changeFeed) broadcast() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for wake := range f.watchers {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run fans out the revisions until ctx is done and watches the storage again after it was interrupted.
+// Revisions are nil if the storage could not be watched.
+func (f *changeFeed) run(ctx context.Context, storage Storage, logger Logger, revisions <-chan int64) {
+	defer close(f.done)
+
+	for {
+		if revisions != nil {
+			for range revisions {
+				f.broadcast()
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warn("watching of changes was interrupted", nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(feedRetryInterval):
+		}
+
+		var err error
+		if revisions, err = storage.WatchRevisions(ctx); err != nil {
+			logger.Error("error while watching changes", map[string]interface{}{"error": err})
+			revisions = nil
+		}
+
+		// Watchers catch up with the changes missed while the storage was not watched.
+		f.broadcast()
+	}
+}
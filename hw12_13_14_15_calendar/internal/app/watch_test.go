@@ -0,0 +1,86 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	calendar := app.New(logg, memorystorage.New(logg), time.Monday)
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	anotherCtx := app.ContextWithUserID(context.Background(), "another user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+
+	_, err = calendar.CreateEvent(ctx, models.Event{Header: "before watching", EventTime: start})
+	require.NoError(t, err)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changes, err := calendar.WatchEvents(watchCtx, 0)
+	require.NoError(t, err)
+
+	_, err = calendar.CreateEvent(anotherCtx, models.Event{Header: "another user", EventTime: start})
+	require.NoError(t, err)
+	id, err := calendar.CreateEvent(ctx, models.Event{Header: "meeting", EventTime: start})
+	require.NoError(t, err)
+	require.NoError(t, calendar.UpdateEvent(ctx, models.Event{ID: id, Header: "planning", EventTime: start}))
+	require.NoError(t, calendar.DeleteEvent(ctx, id, 0))
+
+	received := receiveChanges(t, changes, 3)
+	require.Equal(t, models.ChangeCreated, received[0].Type)
+	require.Equal(t, "meeting", received[0].Event.Header)
+	require.Equal(t, id, received[0].Event.ID)
+	require.Equal(t, models.ChangeUpdated, received[1].Type)
+	require.Equal(t, "planning", received[1].Event.Header)
+	require.Equal(t, models.ChangeDeleted, received[2].Type)
+	require.Equal(t, id, received[2].Event.ID)
+	require.Less(t, received[0].Revision, received[1].Revision)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-changes
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	// The watcher resumes after the last revision it has seen.
+	resumed, err := calendar.WatchEvents(ctx, received[0].Revision)
+	require.NoError(t, err)
+	require.Equal(t, received[1:], receiveChanges(t, resumed, 2))
+
+	_, err = calendar.WatchEvents(ctx, -1)
+	require.ErrorIs(t, err, app.ErrInvalidArgument)
+	_, err = calendar.WatchEvents(context.Background(), 0)
+	require.ErrorIs(t, err, app.ErrUserNotSpecified)
+
+	// Streams end with the App.
+	calendar.Close()
+	require.Eventually(t, func() bool {
+		_, ok := <-resumed
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func receiveChanges(t *testing.T, changes <-chan models.Change, amount int) []models.Change {
+	t.Helper()
+	received := make([]models.Change, 0, amount)
+	for len(received) < amount {
+		select {
+		case change, ok := <-changes:
+			require.True(t, ok, "changes were closed")
+			received = append(received, change)
+		case <-time.After(5 * time.Second):
+			require.Failf(t, "changes were not received", "received %d of %d", len(received), amount)
+		}
+	}
+
+	return received
+}
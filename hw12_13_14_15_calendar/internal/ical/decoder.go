@@ -0,0 +1,358 @@
+package ical
+
+//nolint:depguard
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+var durationRegexp = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type alarm struct {
+	trigger property
+}
+
+type component struct {
+	props  []property
+	alarms []alarm
+}
+
+func (c component) get(name string) (property, bool) {
+	for _, prop := range c.props {
+		if prop.name == name {
+			return prop, true
+		}
+	}
+
+	return property{}, false
+}
+
+// Decode parses VEVENTs of a VCALENDAR object into events. Overridden instances (RECURRENCE-ID)
+// of series that are present in the same calendar are skipped, other instances become single events.
+func Decode(r io.Reader) ([]models.Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	components, err := parseComponents(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	masters := make(map[string]bool)
+	for _, comp := range components {
+		if _, ok := comp.get("RRULE"); ok {
+			uid, _ := comp.get("UID")
+			masters[uid.value] = true
+		}
+	}
+
+	events := make([]models.Event, 0, len(components))
+	for _, comp := range components {
+		if _, ok := comp.get("RECURRENCE-ID"); ok {
+			if uid, _ := comp.get("UID"); masters[uid.value] {
+				continue
+			}
+		}
+
+		event, err := decodeEvent(comp)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading calendar: %w", err)
+	}
+
+	return lines, nil
+}
+
+func parseComponents(lines []string) ([]component, error) {
+	components := make([]component, 0)
+	var current *component
+	var currentAlarm *alarm
+	var nested []string
+
+	for _, line := range lines {
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case prop.name == "BEGIN":
+			nested = append(nested, strings.ToUpper(prop.value))
+			switch strings.ToUpper(prop.value) {
+			case "VEVENT":
+				current = &component{}
+			case "VALARM":
+				if current != nil {
+					currentAlarm = &alarm{}
+				}
+			}
+		case prop.name == "END":
+			if len(nested) == 0 || nested[len(nested)-1] != strings.ToUpper(prop.value) {
+				return nil, fmt.Errorf("unexpected END:%s", prop.value)
+			}
+			nested = nested[:len(nested)-1]
+
+			switch strings.ToUpper(prop.value) {
+			case "VEVENT":
+				if current != nil {
+					components = append(components, *current)
+				}
+				current = nil
+			case "VALARM":
+				if current != nil && currentAlarm != nil {
+					current.alarms = append(current.alarms, *currentAlarm)
+				}
+				currentAlarm = nil
+			}
+		case currentAlarm != nil:
+			if prop.name == "TRIGGER" {
+				currentAlarm.trigger = prop
+			}
+		case current != nil && nested[len(nested)-1] == "VEVENT":
+			current.props = append(current.props, prop)
+		}
+	}
+
+	if len(nested) != 0 {
+		return nil, fmt.Errorf("unterminated component %s", nested[len(nested)-1])
+	}
+
+	return components, nil
+}
+
+func parseProperty(line string) (property, error) {
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+
+	if colon == -1 {
+		return property{}, fmt.Errorf("invalid content line: %q", line)
+	}
+
+	head := strings.Split(line[:colon], ";")
+	prop := property{
+		name:   strings.ToUpper(head[0]),
+		params: make(map[string]string, len(head)-1),
+		value:  line[colon+1:],
+	}
+
+	for _, param := range head[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+
+	return prop, nil
+}
+
+func decodeEvent(comp component) (models.Event, error) {
+	var event models.Event
+
+	start, ok := comp.get("DTSTART")
+	if !ok {
+		return event, fmt.Errorf("VEVENT without DTSTART")
+	}
+
+	var err error
+	event.EventTime, err = parseDateTime(start)
+	if err != nil {
+		return event, err
+	}
+
+	if tzid, ok := start.params["TZID"]; ok && location(start) != time.UTC {
+		event.TimeZone = tzid
+	}
+
+	if end, ok := comp.get("DTEND"); ok {
+		finish, err := parseDateTime(end)
+		if err != nil {
+			return event, err
+		}
+		event.FinishEventTime = &finish
+	} else if duration, ok := comp.get("DURATION"); ok {
+		d, err := parseDuration(duration.value)
+		if err != nil {
+			return event, err
+		}
+		finish := event.EventTime.Add(d)
+		event.FinishEventTime = &finish
+	}
+
+	if summary, ok := comp.get("SUMMARY"); ok {
+		event.Header = unescapeText(summary.value)
+	}
+
+	if description, ok := comp.get("DESCRIPTION"); ok {
+		event.Description = unescapeText(description.value)
+	}
+
+	if rule, ok := comp.get("RRULE"); ok {
+		event.Recurrence = rule.value
+	}
+
+	for _, prop := range comp.props {
+		if prop.name != "EXDATE" {
+			continue
+		}
+
+		for _, value := range strings.Split(prop.value, ",") {
+			exDate, err := parseDateTime(property{name: prop.name, params: prop.params, value: value})
+			if err != nil {
+				return event, err
+			}
+			event.ExDates = append(event.ExDates, exDate)
+		}
+	}
+
+	for _, a := range comp.alarms {
+		notification, err := alarmTime(a, event)
+		if err != nil {
+			return event, err
+		}
+
+		if event.NotificationTime == nil || notification.Before(*event.NotificationTime) {
+			event.NotificationTime = &notification
+		}
+	}
+
+	return event, nil
+}
+
+func alarmTime(a alarm, event models.Event) (time.Time, error) {
+	if a.trigger.name == "" {
+		return time.Time{}, fmt.Errorf("VALARM without TRIGGER")
+	}
+
+	if strings.EqualFold(a.trigger.params["VALUE"], "DATE-TIME") {
+		return parseDateTime(a.trigger)
+	}
+
+	d, err := parseDuration(a.trigger.value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if strings.EqualFold(a.trigger.params["RELATED"], "END") && event.FinishEventTime != nil {
+		return event.FinishEventTime.Add(d), nil
+	}
+
+	return event.EventTime.Add(d), nil
+}
+
+func parseDateTime(prop property) (time.Time, error) {
+	value := strings.TrimSpace(prop.value)
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, location(prop))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date of %s: %w", prop.name, err)
+		}
+
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date-time of %s: %w", prop.name, err)
+		}
+
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(strings.TrimSuffix(dateTimeLayout, "Z"), value, location(prop))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date-time of %s: %w", prop.name, err)
+	}
+
+	return t, nil
+}
+
+// location resolves TZID parameter, floating times are treated as UTC.
+func location(prop property) *time.Location {
+	if tzid, ok := prop.params["TZID"]; ok {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return loc
+		}
+	}
+
+	return time.UTC
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	matches := durationRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid duration: %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if matches[i+2] == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(matches[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %q", value)
+		}
+		d += time.Duration(n) * unit
+	}
+
+	if matches[1] == "-" {
+		d = -d
+	}
+
+	return d, nil
+}
+
+func unescapeText(text string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return replacer.Replace(text)
+}
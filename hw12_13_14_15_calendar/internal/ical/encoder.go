@@ -0,0 +1,136 @@
+package ical
+
+//nolint:depguard
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	prodID         = "-//otus_hw//calendar//EN"
+	dateTimeLayout = "20060102T150405Z"
+	dateLayout     = "20060102"
+	maxLineLength  = 75
+)
+
+// Encode renders events as a VCALENDAR object. Occurrences of recurring events are written
+// as separate VEVENTs bound to their series by UID and RECURRENCE-ID.
+func Encode(events []models.Event, stamp time.Time) []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN", "VCALENDAR")
+	writeLine(&buf, "VERSION", "2.0")
+	writeLine(&buf, "PRODID", prodID)
+	writeLine(&buf, "CALSCALE", "GREGORIAN")
+
+	for _, event := range events {
+		encodeEvent(&buf, event, stamp)
+	}
+
+	writeLine(&buf, "END", "VCALENDAR")
+
+	return buf.Bytes()
+}
+
+func encodeEvent(buf *bytes.Buffer, event models.Event, stamp time.Time) {
+	writeLine(buf, "BEGIN", "VEVENT")
+	writeLine(buf, "UID", event.ID)
+	writeLine(buf, "DTSTAMP", formatDateTime(stamp))
+	writeLine(buf, "DTSTART", formatDateTime(event.EventTime))
+
+	if event.FinishEventTime != nil {
+		writeLine(buf, "DTEND", formatDateTime(*event.FinishEventTime))
+	}
+
+	if event.IsRecurring() {
+		writeLine(buf, "RECURRENCE-ID", formatDateTime(event.EventTime))
+	}
+
+	writeLine(buf, "SUMMARY", escapeText(event.Header))
+	if event.Description != "" {
+		writeLine(buf, "DESCRIPTION", escapeText(event.Description))
+	}
+
+	if event.NotificationTime != nil {
+		writeLine(buf, "BEGIN", "VALARM")
+		writeLine(buf, "ACTION", "DISPLAY")
+		writeLine(buf, "DESCRIPTION", escapeText(event.Header))
+		writeLine(buf, "TRIGGER", formatDuration(event.NotificationTime.Sub(event.EventTime)))
+		writeLine(buf, "END", "VALARM")
+	}
+
+	writeLine(buf, "END", "VEVENT")
+}
+
+// writeLine writes a content line folded to 75 octets as required by RFC 5545.
+func writeLine(buf *bytes.Buffer, name, value string) {
+	line := name + ":" + value
+	for len(line) > maxLineLength {
+		cut := maxLineLength
+		// Do not split multi-byte UTF-8 sequences.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+	}
+
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	d = d.Truncate(time.Second)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	var b strings.Builder
+	b.WriteString(sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+	notification := start.Add(-15 * time.Minute)
+	events := []models.Event{
+		{
+			ID:               "1",
+			Header:           "Planning; sprint 5, team A",
+			Description:      strings.Repeat("long description ", 10) + "\nsecond line",
+			EventTime:        start,
+			FinishEventTime:  &finish,
+			NotificationTime: &notification,
+		},
+		{
+			ID:        "2",
+			Header:    "Без окончания",
+			EventTime: start.Add(24 * time.Hour),
+		},
+	}
+
+	data := Encode(events, start)
+	for _, line := range strings.Split(string(data), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLineLength+1)
+	}
+	require.Contains(t, string(data), "TRIGGER:-PT15M\r\n")
+
+	decoded, err := Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+
+	for i := range events {
+		events[i].ID = ""
+	}
+	require.Equal(t, events, decoded)
+}
+
+func TestEncodeOccurrence(t *testing.T) {
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	data := Encode([]models.Event{{
+		ID:         "series",
+		Header:     "standup",
+		EventTime:  start,
+		Recurrence: "FREQ=DAILY",
+	}}, start)
+
+	require.Contains(t, string(data), "UID:series\r\n")
+	require.Contains(t, string(data), "RECURRENCE-ID:20240110T090000Z\r\n")
+	require.NotContains(t, string(data), "RRULE")
+}
+
+func TestDecode(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Google Inc//Google Calendar 70.9054//EN",
+		"BEGIN:VEVENT",
+		"UID:weekly@google.com",
+		"DTSTART;TZID=Europe/Berlin:20240108T100000",
+		"DURATION:PT30M",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"EXDATE;TZID=Europe/Berlin:20240115T100000,20240122T100000",
+		"SUMMARY:Weekly",
+		" standup",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER;RELATED=END:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:weekly@google.com",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20240129T100000",
+		"DTSTART;TZID=Europe/Berlin:20240129T110000",
+		"SUMMARY:Moved standup",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:holiday@google.com",
+		"DTSTART;VALUE=DATE:20240101",
+		"SUMMARY:New year",
+		"BEGIN:VALARM",
+		"TRIGGER;VALUE=DATE-TIME:20231231T120000Z",
+		"END:VALARM",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Decode(strings.NewReader(calendar))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	weekly := events[0]
+	require.Equal(t, "Weeklystandup", weekly.Header)
+	require.True(t, weekly.EventTime.Equal(time.Date(2024, time.January, 8, 10, 0, 0, 0, berlin)))
+	require.Equal(t, 30*time.Minute, weekly.FinishEventTime.Sub(weekly.EventTime))
+	require.Equal(t, 20*time.Minute, weekly.NotificationTime.Sub(weekly.EventTime))
+	require.Equal(t, "FREQ=WEEKLY;BYDAY=MO", weekly.Recurrence)
+	require.Equal(t, "Europe/Berlin", weekly.TimeZone)
+	require.Len(t, weekly.ExDates, 2)
+
+	holiday := events[1]
+	require.Equal(t, "New year", holiday.Header)
+	require.Empty(t, holiday.TimeZone)
+	require.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), holiday.EventTime)
+	require.Equal(t, time.Date(2023, time.December, 31, 12, 0, 0, 0, time.UTC), *holiday.NotificationTime)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar string
+	}{
+		{name: "no dtstart", calendar: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:x\r\nEND:VEVENT\r\nEND:VCALENDAR"},
+		{name: "unterminated", calendar: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20240101T000000Z"},
+		{name: "invalid line", calendar: "BEGIN:VCALENDAR\r\ninvalid\r\nEND:VCALENDAR"},
+		{name: "invalid date", calendar: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:2024\r\nEND:VEVENT\r\nEND:VCALENDAR"},
+		{name: "mismatched end", calendar: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(test.calendar))
+			require.Error(t, err)
+		})
+	}
+}
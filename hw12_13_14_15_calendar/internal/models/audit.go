@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditEntry is a change of an event made by Actor. UserID is the owner of the event,
+// Before is empty for created events and After is empty for deleted ones.
+type AuditEntry struct {
+	ID      int64       `json:"id"`
+	EventID string      `json:"eventId"`
+	UserID  string      `json:"userId"`
+	Actor   string      `json:"actor"`
+	Action  AuditAction `json:"action"`
+	Before  *Event      `json:"before,omitempty"`
+	After   *Event      `json:"after,omitempty"`
+	Time    time.Time   `json:"time"`
+}
@@ -0,0 +1,62 @@
+package models
+
+//nolint:depguard
+import (
+	"errors"
+	"fmt"
+)
+
+type BatchOperation string
+
+const (
+	BatchCreate BatchOperation = "create"
+	BatchUpdate BatchOperation = "update"
+	BatchDelete BatchOperation = "delete"
+)
+
+// BatchItem is a single change of a batch. Delete uses only the ID and the expected Version of the event.
+type BatchItem struct {
+	Operation BatchOperation `json:"op"`
+	Event     Event          `json:"event"`
+}
+
+// BatchResult is the outcome of the batch item with the same index. Event is the stored state of the event.
+type BatchResult struct {
+	ID    string
+	Event Event
+	Err   error
+}
+
+// AuditAction is the action recorded in the audit log for the item.
+func (i BatchItem) AuditAction() AuditAction {
+	switch i.Operation {
+	case BatchUpdate:
+		return AuditUpdate
+	case BatchDelete:
+		return AuditDelete
+	default:
+		return AuditCreate
+	}
+}
+
+func (i BatchItem) Validate() error {
+	switch i.Operation {
+	case BatchCreate:
+	case BatchUpdate, BatchDelete:
+		if i.Event.ID == "" {
+			return errors.New("event id is required parameter")
+		}
+	default:
+		return fmt.Errorf("unknown batch operation %q", i.Operation)
+	}
+
+	if i.Event.Version < 0 {
+		return errors.New("version must not be negative")
+	}
+
+	if i.Operation == BatchDelete {
+		return nil
+	}
+
+	return i.Event.Validate()
+}
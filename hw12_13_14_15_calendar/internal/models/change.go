@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change is an audit entry as seen by watchers of events. Revision is the id of the audit entry,
+// it grows with every change, so a watcher resumes after the last revision it has seen.
+type Change struct {
+	Revision int64      `json:"revision"`
+	Type     ChangeType `json:"type"`
+	Event    Event      `json:"event"`
+	Time     time.Time  `json:"time"`
+}
+
+// ChangeOf converts the audit entry, a restored event is reported as created again.
+func ChangeOf(entry AuditEntry) Change {
+	change := Change{
+		Revision: entry.ID,
+		Type:     ChangeUpdated,
+		Event:    Event{ID: entry.EventID, UserID: entry.UserID},
+		Time:     entry.Time,
+	}
+
+	snapshot := entry.After
+	switch entry.Action {
+	case AuditCreate, AuditRestore:
+		change.Type = ChangeCreated
+	case AuditDelete:
+		change.Type, snapshot = ChangeDeleted, entry.Before
+	case AuditUpdate:
+	}
+
+	if snapshot != nil {
+		change.Event = *snapshot
+	}
+
+	return change
+}
+
+// Within reports whether the changed event happens in [from, to), zero bounds are unbounded.
+// A recurring series is within the window if it starts before the end of the window.
+func (c Change) Within(from, to time.Time) bool {
+	if !to.IsZero() && !c.Event.EventTime.Before(to) {
+		return false
+	}
+
+	return c.Event.Recurrence != "" || from.IsZero() || !c.Event.EventTime.Before(from)
+}
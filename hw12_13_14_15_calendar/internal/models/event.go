@@ -1,13 +1,52 @@
 package models
 
-import "time"
+//nolint:depguard
+import (
+	"errors"
+	"time"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/validator"
+)
+
+// Lengths of text fields are limited by the columns of the events table.
 type Event struct {
-	ID               string     `json:"id"`
-	Header           string     `json:"header"`
-	Description      string     `json:"description"`
-	UserID           string     `json:"userId"`
-	EventTime        time.Time  `json:"eventTime"`
-	FinishEventTime  *time.Time `json:"finishEventTime,omitempty"`
-	NotificationTime *time.Time `json:"notificationTime,omitempty"`
+	ID               string      `json:"id"`
+	Header           string      `json:"header" validate:"max:255"`
+	Description      string      `json:"description" validate:"max:255"`
+	UserID           string      `json:"userId" validate:"max:255"`
+	EventTime        time.Time   `json:"eventTime"`
+	FinishEventTime  *time.Time  `json:"finishEventTime,omitempty" validate:"after:EventTime"`
+	NotificationTime *time.Time  `json:"notificationTime,omitempty" validate:"before:EventTime"`
+	Recurrence       string      `json:"recurrence,omitempty" validate:"max:255"`
+	ExDates          []time.Time `json:"exDates,omitempty"`
+	TimeZone         string      `json:"timeZone,omitempty" validate:"max:64"`
+	// RemindBefore sets NotificationTime this long before EventTime, also when EventTime moves.
+	RemindBefore Duration `json:"remindBefore,omitempty" validate:"min:0"`
+	// Version grows on every change of the event. When an event is written,
+	// a non-zero Version is the version the caller expects to overwrite.
+	Version int64 `json:"version,omitempty"`
+	// DeletedAt is set while the event is in the trash.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Validate returns validator.ValidationErrors with every invalid field of the event.
+func (e Event) Validate() error {
+	var validationErrors validator.ValidationErrors
+	if err := validator.Validate(e); err != nil && !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	if err := ValidateRecurrence(e.Recurrence); err != nil {
+		validationErrors = append(validationErrors, validator.ValidationError{Field: "recurrence", Err: err})
+	}
+
+	if err := ValidateTimeZone(e.TimeZone); err != nil {
+		validationErrors = append(validationErrors, validator.ValidationError{Field: "timeZone", Err: err})
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	return nil
 }
@@ -1,10 +1,106 @@
 package models
 
-import "time"
+//nolint:depguard
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
 
 type Notification struct {
-	ID          string    `json:"eventId"`
-	EventHeader string    `json:"eventHeader"`
-	EventTime   time.Time `json:"eventTime"`
-	UserID      string    `json:"userId"`
+	ID               string    `json:"eventId"`
+	EventHeader      string    `json:"eventHeader"`
+	EventTime        time.Time `json:"eventTime"`
+	UserID           string    `json:"userId"`
+	NotificationTime time.Time `json:"notificationTime"`
+}
+
+// NotificationState is the state of a notification in the ledger of sent notifications.
+type NotificationState string
+
+const (
+	NotificationPublished NotificationState = "published"
+	// NotificationDelivering is the state of a notification claimed by a sender until it is delivered.
+	NotificationDelivering NotificationState = "delivering"
+	NotificationDelivered  NotificationState = "delivered"
+)
+
+// Key identifies the notification of an occurrence of the event, it is the idempotency key of its messages.
+func (n Notification) Key() string {
+	return n.ID + "/" + n.EventTime.UTC().Format(time.RFC3339)
+}
+
+// Duration is a time.Duration written in JSON as a string like "1h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\": %w", err)
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+
+	return nil
+}
+
+// WithReminder returns the event notified RemindBefore ahead of its start,
+// events without RemindBefore keep their NotificationTime.
+func (e Event) WithReminder() Event {
+	if e.RemindBefore <= 0 {
+		return e
+	}
+
+	notification := e.EventTime.Add(-time.Duration(e.RemindBefore))
+	e.NotificationTime = &notification
+
+	return e
+}
+
+// NotificationsIn returns notifications of the event, or of the occurrences of a recurring event,
+// whose notification time is within [from, to).
+func (e Event) NotificationsIn(from, to time.Time) ([]Notification, error) {
+	if e.NotificationTime == nil {
+		return nil, nil
+	}
+
+	// An occurrence notifies within the window if it starts within the window shifted by the lead time.
+	lead := e.EventTime.Sub(*e.NotificationTime)
+	occurrences, err := e.Occurrences(from.Add(lead), to.Add(lead))
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]Notification, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		notifications = append(notifications, Notification{
+			ID:               e.ID,
+			EventHeader:      occurrence.Header,
+			EventTime:        occurrence.EventTime,
+			UserID:           occurrence.UserID,
+			NotificationTime: *occurrence.NotificationTime,
+		})
+	}
+
+	return notifications, nil
+}
+
+// SortNotifications orders notifications by the time they are due and by their keys.
+func SortNotifications(notifications []Notification) {
+	sort.Slice(notifications, func(i, j int) bool {
+		if !notifications[i].NotificationTime.Equal(notifications[j].NotificationTime) {
+			return notifications[i].NotificationTime.Before(notifications[j].NotificationTime)
+		}
+
+		return notifications[i].Key() < notifications[j].Key()
+	})
 }
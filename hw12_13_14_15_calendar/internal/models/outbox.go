@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OutboxMessage is a notification waiting in the outbox until it is published to the broker.
+// NextAttempt is when the message is published again after Attempts failed ones.
+type OutboxMessage struct {
+	ID           int64        `json:"id"`
+	Notification Notification `json:"notification"`
+	Attempts     int          `json:"attempts"`
+	NextAttempt  time.Time    `json:"nextAttempt"`
+	LastError    string       `json:"lastError,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	SentAt       *time.Time   `json:"sentAt,omitempty"`
+}
+
+// OutboxStats counts unsent messages of the outbox, Stuck ones wait longer than expected.
+type OutboxStats struct {
+	Pending int       `json:"pending"`
+	Stuck   int       `json:"stuck"`
+	Oldest  time.Time `json:"oldest"`
+}
@@ -0,0 +1,32 @@
+package models
+
+//nolint:depguard
+import (
+	"sort"
+	"time"
+)
+
+// Cursor points to the last event of a page, listings are ordered by start time and then by id.
+type Cursor struct {
+	EventTime time.Time
+	ID        string
+}
+
+func CursorOf(event Event) Cursor {
+	return Cursor{EventTime: event.EventTime, ID: event.ID}
+}
+
+// Precedes reports whether the event goes after the cursor.
+func (c Cursor) Precedes(event Event) bool {
+	if !c.EventTime.Equal(event.EventTime) {
+		return c.EventTime.Before(event.EventTime)
+	}
+
+	return c.ID < event.ID
+}
+
+func SortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return CursorOf(events[i]).Precedes(events[j])
+	})
+}
@@ -0,0 +1,92 @@
+package models
+
+//nolint:depguard
+import "fmt"
+
+// EventPatch sets Fields of an event to the values they have in Event, fields are named as in JSON.
+// A listed field with zero value is cleared.
+type EventPatch struct {
+	Event  Event
+	Fields []string
+}
+
+var patchers = map[string]func(dst *Event, src Event){
+	"header":           func(dst *Event, src Event) { dst.Header = src.Header },
+	"description":      func(dst *Event, src Event) { dst.Description = src.Description },
+	"eventTime":        func(dst *Event, src Event) { dst.EventTime = src.EventTime },
+	"finishEventTime":  func(dst *Event, src Event) { dst.FinishEventTime = src.FinishEventTime },
+	"notificationTime": func(dst *Event, src Event) { dst.NotificationTime = src.NotificationTime },
+	"remindBefore":     func(dst *Event, src Event) { dst.RemindBefore = src.RemindBefore },
+	"recurrence":       func(dst *Event, src Event) { dst.Recurrence = src.Recurrence },
+	"exDates":          func(dst *Event, src Event) { dst.ExDates = src.ExDates },
+	"timeZone":         func(dst *Event, src Event) { dst.TimeZone = src.TimeZone },
+}
+
+func (p EventPatch) Validate() error {
+	listed := make(map[string]struct{}, len(p.Fields))
+	for _, field := range p.Fields {
+		if _, ok := patchers[field]; !ok {
+			return fmt.Errorf("field %q can not be patched", field)
+		}
+
+		if _, ok := listed[field]; ok {
+			return fmt.Errorf("field %q is listed twice", field)
+		}
+		listed[field] = struct{}{}
+	}
+
+	return nil
+}
+
+func (p EventPatch) Has(field string) bool {
+	for _, f := range p.Fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply returns a copy of the event with the patched fields, unknown fields are ignored.
+func (p EventPatch) Apply(event Event) Event {
+	for _, field := range p.Fields {
+		if patch, ok := patchers[field]; ok {
+			patch(&event, p.Event)
+		}
+	}
+
+	return event
+}
+
+// WithReminder keeps NotificationTime of the patched event RemindBefore ahead of its start.
+// A patched NotificationTime without RemindBefore turns RemindBefore off, zero RemindBefore clears the notification.
+func (p EventPatch) WithReminder(current Event) EventPatch {
+	switch {
+	case p.Has("notificationTime") && !p.Has("remindBefore"):
+		p.Event.RemindBefore = 0
+		return p.with("remindBefore")
+	case p.Has("remindBefore") && p.Event.RemindBefore == 0 && !p.Has("notificationTime"):
+		p.Event.NotificationTime = nil
+		return p.with("notificationTime")
+	}
+
+	patched := p.Apply(current)
+	if patched.RemindBefore <= 0 || !p.Has("eventTime") && !p.Has("remindBefore") {
+		return p
+	}
+
+	p.Event.NotificationTime = patched.WithReminder().NotificationTime
+
+	return p.with("notificationTime")
+}
+
+func (p EventPatch) with(field string) EventPatch {
+	if p.Has(field) {
+		return p
+	}
+
+	p.Fields = append(append(make([]string, 0, len(p.Fields)+1), p.Fields...), field)
+
+	return p
+}
@@ -0,0 +1,111 @@
+package models
+
+//nolint:depguard
+import (
+	"fmt"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/rrule"
+)
+
+func (e Event) IsRecurring() bool {
+	return e.Recurrence != ""
+}
+
+// Occurrences returns a copy of the event for every occurrence that starts within [from, to).
+// Finish and notification times of an occurrence keep their offsets from its start.
+func (e Event) Occurrences(from, to time.Time) ([]Event, error) {
+	if !e.IsRecurring() {
+		if !e.EventTime.Before(from) && e.EventTime.Before(to) {
+			return []Event{e}, nil
+		}
+
+		return nil, nil
+	}
+
+	rule, err := rrule.Parse(e.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence of event %s: %w", e.ID, err)
+	}
+
+	// The wall clock time of a series is kept in its own time zone across DST transitions.
+	dtstart := e.EventTime
+	if e.TimeZone != "" {
+		loc, err := time.LoadLocation(e.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone of event %s: %w", e.ID, err)
+		}
+		dtstart = dtstart.In(loc)
+	}
+
+	starts := rule.Between(dtstart, from, to, e.ExDates)
+	occurrences := make([]Event, 0, len(starts))
+	for _, start := range starts {
+		occurrences = append(occurrences, e.occurrence(start))
+	}
+
+	return occurrences, nil
+}
+
+func (e Event) occurrence(start time.Time) Event {
+	shift := start.Sub(e.EventTime)
+	occurrence := e
+	occurrence.EventTime = start
+
+	if e.FinishEventTime != nil {
+		finish := e.FinishEventTime.Add(shift)
+		occurrence.FinishEventTime = &finish
+	}
+
+	if e.NotificationTime != nil {
+		notification := e.NotificationTime.Add(shift)
+		occurrence.NotificationTime = &notification
+	}
+
+	return occurrence
+}
+
+// ExpandOccurrences replaces every recurring event with its occurrences within [from, to).
+// Single events are passed through untouched.
+func ExpandOccurrences(events []Event, from, to time.Time) ([]Event, error) {
+	expanded := make([]Event, 0, len(events))
+	for _, event := range events {
+		if !event.IsRecurring() {
+			expanded = append(expanded, event)
+			continue
+		}
+
+		occurrences, err := event.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded = append(expanded, occurrences...)
+	}
+
+	return expanded, nil
+}
+
+func ValidateRecurrence(recurrence string) error {
+	if recurrence == "" {
+		return nil
+	}
+
+	if _, err := rrule.Parse(recurrence); err != nil {
+		return fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+
+	return nil
+}
+
+func ValidateTimeZone(timeZone string) error {
+	if timeZone == "" {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return fmt.Errorf("invalid time zone: %w", err)
+	}
+
+	return nil
+}
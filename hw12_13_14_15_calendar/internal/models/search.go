@@ -0,0 +1,45 @@
+package models
+
+//nolint:depguard
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchQuery selects events by words of Header and Description. UserID, From and To are optional,
+// the range is applied to the start of an event, so recurring series are found by their first occurrence.
+type SearchQuery struct {
+	Text   string
+	UserID string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// Matches checks the user and the range of the query, the text is matched by storages.
+func (q SearchQuery) Matches(event Event) bool {
+	if q.UserID != "" && event.UserID != q.UserID {
+		return false
+	}
+
+	if !q.From.IsZero() && event.EventTime.Before(q.From) {
+		return false
+	}
+
+	return q.To.IsZero() || event.EventTime.Before(q.To)
+}
+
+// SearchTerms splits text into lower case words, the same way Postgres 'simple' configuration does.
+func SearchTerms(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, strings.ToLower(word))
+	}
+
+	return terms
+}
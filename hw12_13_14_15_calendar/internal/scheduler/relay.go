@@ -0,0 +1,145 @@
+package scheduler
+
+//nolint:depguard
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/mb"
+)
+
+// relayBatchSize is how many messages of the outbox are published on one run of the relay.
+const relayBatchSize = 100
+
+// metrics of the relay are served by expvar at /debug/vars: published and failed count publishing attempts,
+// pending and stuck are the numbers of unsent messages after the last run.
+var metrics = expvar.NewMap("outbox")
+
+type OutboxStorage interface {
+	GetOutbox(ctx context.Context, now time.Time, limit int) ([]models.OutboxMessage, error)
+	MarkOutboxSent(ctx context.Context, message models.OutboxMessage) error
+	MarkOutboxFailed(ctx context.Context, id int64, nextAttempt time.Time, reason string) error
+	GetOutboxStats(ctx context.Context, stuckBefore time.Time) (models.OutboxStats, error)
+}
+
+// Relay publishes messages of the outbox to the broker. A failed message is retried with a backoff
+// doubling from minBackoff up to maxBackoff, a message unsent for stuckAfter is reported as stuck.
+type Relay struct {
+	logger     Logger
+	storage    OutboxStorage
+	producer   mb.ProducerMB
+	routeKey   string
+	interval   time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	stuckAfter time.Duration
+}
+
+func NewRelay(
+	logger Logger, storage OutboxStorage, producer mb.ProducerMB, routeKey string,
+	interval, minBackoff, maxBackoff, stuckAfter time.Duration,
+) *Relay {
+	return &Relay{
+		logger:     logger,
+		storage:    storage,
+		producer:   producer,
+		routeKey:   routeKey,
+		interval:   interval,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		stuckAfter: stuckAfter,
+	}
+}
+
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("stopping outbox relay...", nil)
+			return
+		case <-ticker.C:
+			if err := r.relay(ctx, time.Now()); err != nil {
+				r.logger.Error("error while relaying outbox", map[string]interface{}{"error": err})
+			}
+		}
+	}
+}
+
+// relay publishes messages of the outbox due at now and updates the metrics.
+func (r *Relay) relay(ctx context.Context, now time.Time) error {
+	messages, err := r.storage.GetOutbox(ctx, now, relayBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err = r.publish(ctx, message, now); err != nil {
+			return err
+		}
+	}
+
+	stats, err := r.storage.GetOutboxStats(ctx, now.Add(-r.stuckAfter))
+	if err != nil {
+		return err
+	}
+	metrics.Set("pending", intVar(stats.Pending))
+	metrics.Set("stuck", intVar(stats.Stuck))
+
+	if stats.Stuck > 0 {
+		r.logger.Warn("outbox has stuck notifications",
+			map[string]interface{}{"stuck": stats.Stuck, "pending": stats.Pending, "oldest": stats.Oldest})
+	}
+
+	return nil
+}
+
+// publish sends the message, a failed one is put off till the next attempt.
+// Only errors of the storage are returned, the relay stops then till the next run.
+func (r *Relay) publish(ctx context.Context, message models.OutboxMessage, now time.Time) error {
+	data, err := json.Marshal(message.Notification)
+	if err == nil {
+		err = r.producer.Publish(r.routeKey, message.Notification.Key(), data)
+	}
+
+	if err != nil {
+		metrics.Add("failed", 1)
+		nextAttempt := now.Add(r.backoff(message.Attempts))
+		r.logger.Error("error while publishing notification", map[string]interface{}{
+			"error": err, "key": message.Notification.Key(), "attempts": message.Attempts + 1, "next attempt": nextAttempt,
+		})
+
+		return r.storage.MarkOutboxFailed(ctx, message.ID, nextAttempt, err.Error())
+	}
+
+	metrics.Add("published", 1)
+
+	// The sender drops the message if it is published again because it was not marked sent.
+	return r.storage.MarkOutboxSent(ctx, message)
+}
+
+// backoff returns the delay before the next attempt after the given number of failed ones.
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.minBackoff
+	for i := 0; i < attempts && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > r.maxBackoff {
+		return r.maxBackoff
+	}
+
+	return backoff
+}
+
+func intVar(value int) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(int64(value))
+
+	return v
+}
@@ -3,19 +3,18 @@ package scheduler
 //nolint:depguard
 import (
 	"context"
-	"encoding/json"
 	"time"
-
-	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
-	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/mb"
 )
 
+// watermarkName is the name the scheduler saves the end of its last enqueued window under.
+const watermarkName = "notifications"
+
 type Scheduler struct {
-	logger    Logger
-	storage   Storage
-	producer  mb.ProducerMB
-	routeKey  string
-	frequency time.Duration
+	logger   Logger
+	storage  Storage
+	interval time.Duration
+	// trashRetention is how long deleted events stay in the trash before they are purged.
+	trashRetention time.Duration
 }
 
 type Logger interface {
@@ -27,17 +26,23 @@ type Logger interface {
 }
 
 type Storage interface {
-	DeleteOldEvent(ctx context.Context) error
-	GetNotifications(ctx context.Context) ([]models.Notification, error)
+	PurgeDeletedEvents(ctx context.Context, deletedBefore time.Time) error
+	GetWatermark(ctx context.Context, name string) (time.Time, error)
+	EnqueueNotifications(ctx context.Context, watermarkName string, from, to time.Time) (int, error)
 	Close()
 }
 
-func New(logger Logger, storage Storage, producer mb.ProducerMB, frequency time.Duration, routeKey string) *Scheduler {
-	return &Scheduler{logger: logger, storage: storage, producer: producer, frequency: frequency, routeKey: routeKey}
+func New(logger Logger, storage Storage, interval time.Duration, trashRetention time.Duration) *Scheduler {
+	return &Scheduler{
+		logger:         logger,
+		storage:        storage,
+		interval:       interval,
+		trashRetention: trashRetention,
+	}
 }
 
 func (s *Scheduler) Start(ctx context.Context) {
-	ticker := time.NewTicker(s.frequency)
+	ticker := time.NewTicker(s.interval)
 	defer ticker.Stop()
 
 	for {
@@ -46,31 +51,40 @@ func (s *Scheduler) Start(ctx context.Context) {
 			s.logger.Info("stopping scheduler...", nil)
 			return
 		case <-ticker.C:
-			s.logger.Info("deleting old events...", map[string]interface{}{"time": time.Now()})
-			err := s.storage.DeleteOldEvent(ctx)
+			s.logger.Info("purging deleted events...", map[string]interface{}{"time": time.Now()})
+			err := s.storage.PurgeDeletedEvents(ctx, time.Now().Add(-s.trashRetention))
 			if err != nil {
-				s.logger.Error("error while deleting old events", map[string]interface{}{"error": err})
+				s.logger.Error("error while purging deleted events", map[string]interface{}{"error": err})
 			}
 
-			s.logger.Info("getting new notifications...", nil)
-			notifications, err := s.storage.GetNotifications(ctx)
-			if err != nil {
-				s.logger.Error("error while getting new notifications", map[string]interface{}{"error": err})
+			if err = s.notify(ctx, time.Now()); err != nil {
+				s.logger.Error("error while enqueueing notifications", map[string]interface{}{"error": err})
 			}
+		}
+	}
+}
 
-			if len(notifications) != 0 {
-				for _, notification := range notifications {
-					data, err := json.Marshal(notification)
-					if err != nil {
-						s.logger.Error("error while marshaling new notification", map[string]interface{}{"error": err})
-					}
+// notify enqueues notifications due since the watermark till now into the outbox and moves the watermark
+// in the same transaction, so a restarted scheduler neither repeats nor skips notifications.
+func (s *Scheduler) notify(ctx context.Context, now time.Time) error {
+	from, err := s.storage.GetWatermark(ctx, watermarkName)
+	if err != nil {
+		return err
+	}
 
-					err = s.producer.Publish(s.routeKey, data)
-					if err != nil {
-						s.logger.Error("error while publishing new notification", map[string]interface{}{"error": err})
-					}
-				}
-			}
-		}
+	if from.IsZero() {
+		from = now.Add(-s.interval)
+	}
+
+	if !from.Before(now) {
+		return nil
 	}
+
+	enqueued, err := s.storage.EnqueueNotifications(ctx, watermarkName, from, now)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("notifications are enqueued", map[string]interface{}{"from": from, "to": now, "count": enqueued})
+
+	return nil
 }
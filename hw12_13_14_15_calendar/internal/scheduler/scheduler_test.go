@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type producer struct {
+	headers []string
+	keys    []string
+	err     error
+}
+
+func (p *producer) Publish(_, key string, msg []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	var notification models.Notification
+	if err := json.Unmarshal(msg, &notification); err != nil {
+		return err
+	}
+	p.headers = append(p.headers, notification.EventHeader)
+	p.keys = append(p.keys, key)
+
+	return nil
+}
+
+func newStorage(t *testing.T, start time.Time) *memorystorage.Storage {
+	t.Helper()
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := memorystorage.New(logg)
+	for i, header := range []string{"first", "second", "third"} {
+		_, err = storage.CreateEvent(context.Background(), models.Event{
+			Header:       header,
+			EventTime:    start.Add(time.Duration(i) * time.Hour),
+			RemindBefore: models.Duration(time.Duration(i)*time.Hour + 30*time.Second),
+		}.WithReminder())
+		require.NoError(t, err)
+	}
+
+	return storage
+}
+
+func TestNotify(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	// Notifications are due at 9:59:30 for every event.
+	storage := newStorage(t, start)
+	schedule := New(logg, storage, time.Minute, time.Hour)
+
+	require.NoError(t, schedule.notify(ctx, start.Add(-time.Minute)))
+	outbox, err := storage.GetOutbox(ctx, time.Now(), relayBatchSize)
+	require.NoError(t, err)
+	require.Empty(t, outbox)
+
+	require.NoError(t, schedule.notify(ctx, start))
+	watermark, err := storage.GetWatermark(ctx, watermarkName)
+	require.NoError(t, err)
+	require.Equal(t, start, watermark)
+	outbox, err = storage.GetOutbox(ctx, time.Now(), relayBatchSize)
+	require.NoError(t, err)
+	require.Len(t, outbox, 3)
+
+	// A restarted scheduler continues from the watermark, notifications in the outbox are not enqueued again
+	// even if the watermark goes back.
+	schedule = New(logg, storage, time.Minute, time.Hour)
+	require.NoError(t, schedule.notify(ctx, start.Add(time.Minute)))
+	require.NoError(t, storage.SetWatermark(ctx, watermarkName, start.Add(-time.Hour)))
+	require.NoError(t, schedule.notify(ctx, start))
+	outbox, err = storage.GetOutbox(ctx, time.Now(), relayBatchSize)
+	require.NoError(t, err)
+	require.Len(t, outbox, 3)
+}
+
+func TestRelay(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	storage := newStorage(t, start)
+	_, err = storage.EnqueueNotifications(ctx, watermarkName, start.Add(-time.Minute), start)
+	require.NoError(t, err)
+	published := &producer{err: errors.New("broker is down")}
+	relay := NewRelay(logg, storage, published, "route", time.Second, time.Second, 3*time.Second, time.Hour)
+	failed := metric(t, "failed")
+	sent := metric(t, "published")
+
+	now := time.Now()
+	require.NoError(t, relay.relay(ctx, now))
+	require.Equal(t, failed+3, metric(t, "failed"))
+	require.Equal(t, int64(3), metric(t, "pending"))
+	outbox, err := storage.GetOutbox(ctx, now.Add(time.Second), relayBatchSize)
+	require.NoError(t, err)
+	require.Len(t, outbox, 3)
+	require.Equal(t, 1, outbox[0].Attempts)
+	require.Equal(t, "broker is down", outbox[0].LastError)
+
+	// Failed messages wait for the backoff, it doubles up to the maximum.
+	require.NoError(t, relay.relay(ctx, now.Add(time.Second)))
+	require.NoError(t, relay.relay(ctx, now.Add(2*time.Second)))
+	require.Equal(t, failed+6, metric(t, "failed"))
+	require.NoError(t, relay.relay(ctx, now.Add(3*time.Second)))
+	require.Equal(t, failed+9, metric(t, "failed"))
+	require.Equal(t, 3*time.Second, relay.backoff(5))
+
+	// Messages unsent for too long are stuck.
+	relay.stuckAfter = time.Nanosecond
+	require.NoError(t, relay.relay(ctx, now.Add(4*time.Second)))
+	require.Equal(t, int64(3), metric(t, "stuck"))
+
+	published.err = nil
+	require.NoError(t, relay.relay(ctx, now.Add(6*time.Second)))
+	require.ElementsMatch(t, []string{"first", "second", "third"}, published.headers)
+	require.Equal(t, sent+3, metric(t, "published"))
+	require.Equal(t, int64(0), metric(t, "pending"))
+	require.Equal(t, int64(0), metric(t, "stuck"))
+
+	for _, message := range outbox {
+		require.Contains(t, published.keys, message.Notification.Key())
+		state, err := storage.GetNotificationState(ctx, message.Notification)
+		require.NoError(t, err)
+		require.Equal(t, models.NotificationPublished, state)
+	}
+
+	// Sent notifications are not enqueued again.
+	enqueued, err := storage.EnqueueNotifications(ctx, watermarkName, start.Add(-time.Minute), start)
+	require.NoError(t, err)
+	require.Zero(t, enqueued)
+}
+
+func metric(t *testing.T, name string) int64 {
+	t.Helper()
+	value, ok := metrics.Get(name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+
+	return value.Value()
+}
@@ -0,0 +1,66 @@
+package sender
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+// EmailNotifier sends notifications as plain text emails through an SMTP server,
+// it authenticates only if the username is set.
+type EmailNotifier struct {
+	address  string
+	auth     smtp.Auth
+	from     string
+	template *Template
+}
+
+func NewEmailNotifier(host, port, username, password, from string, template *Template) *EmailNotifier {
+	notifier := &EmailNotifier{address: net.JoinHostPort(host, port), from: from, template: template}
+	if username != "" {
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return notifier
+}
+
+func (n *EmailNotifier) Notify(_ context.Context, address string, notification models.Notification) error {
+	if address == "" {
+		return fmt.Errorf("%w: email of user %s", ErrNoAddress, notification.UserID)
+	}
+
+	subject, body, err := n.template.Render(notification)
+	if err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", address)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", encodeHeader(subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&msg, "X-Idempotency-Key: %s\r\n\r\n", notification.Key())
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	if err = smtp.SendMail(n.address, n.auth, n.from, []string{address}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("error while sending email: %w", err)
+	}
+
+	return nil
+}
+
+// encodeHeader keeps a header value rendered from user input on one line, so it can not add headers,
+// and encodes non-ASCII text.
+func encodeHeader(value string) string {
+	value = strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+
+	return mime.QEncoding.Encode("utf-8", value)
+}
@@ -0,0 +1,38 @@
+package sender
+
+//nolint:depguard
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+// LogNotifier writes notifications to a file or to the standard output, one line each.
+type LogNotifier struct {
+	out      io.Writer
+	template *Template
+	mu       sync.Mutex
+}
+
+func NewLogNotifier(out io.Writer, template *Template) *LogNotifier {
+	return &LogNotifier{out: out, template: template}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, _ string, notification models.Notification) error {
+	subject, body, err := n.template.Render(notification)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err = fmt.Fprintf(n.out, "%s\t%s\t%s: %s\n", notification.Key(), notification.UserID, subject, body)
+	if err != nil {
+		return fmt.Errorf("error while writing notification: %w", err)
+	}
+
+	return nil
+}
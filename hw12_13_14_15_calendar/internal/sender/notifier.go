@@ -0,0 +1,93 @@
+package sender
+
+//nolint:depguard
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+const (
+	DefaultSubject = `Reminder: {{.EventHeader}}`
+	DefaultBody    = `{{.EventHeader}} starts at {{.EventTime.Format "2006-01-02 15:04 MST"}}.`
+)
+
+var (
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	ErrNoAddress      = errors.New("no address to notify")
+)
+
+// Notifier delivers a notification to the address on its channel, an email or an URL of a webhook.
+type Notifier interface {
+	Notify(ctx context.Context, address string, notification models.Notification) error
+}
+
+// Preference is the channel a user is notified on and the address on the channel.
+type Preference struct {
+	Channel string
+	Address string
+}
+
+// Router notifies every user on the channel of the user's preference or on the fallback one.
+type Router struct {
+	notifiers   map[string]Notifier
+	preferences map[string]Preference
+	fallback    Preference
+}
+
+func NewRouter(notifiers map[string]Notifier, preferences map[string]Preference, fallback Preference) *Router {
+	return &Router{notifiers: notifiers, preferences: preferences, fallback: fallback}
+}
+
+func (r *Router) Notify(ctx context.Context, notification models.Notification) error {
+	preference, ok := r.preferences[notification.UserID]
+	if !ok {
+		preference = r.fallback
+	}
+
+	notifier, ok := r.notifiers[preference.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownChannel, preference.Channel)
+	}
+
+	return notifier.Notify(ctx, preference.Address, notification)
+}
+
+// Template renders the subject and the body of a message from a notification.
+type Template struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func NewTemplate(subject, body string) (*Template, error) {
+	subjectTemplate, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing subject template: %w", err)
+	}
+
+	bodyTemplate, err := template.New("body").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing body template: %w", err)
+	}
+
+	return &Template{subject: subjectTemplate, body: bodyTemplate}, nil
+}
+
+func (t *Template) Render(notification models.Notification) (subject, body string, err error) {
+	var buf bytes.Buffer
+	if err = t.subject.Execute(&buf, notification); err != nil {
+		return "", "", fmt.Errorf("error while rendering subject: %w", err)
+	}
+	subject = buf.String()
+
+	buf.Reset()
+	if err = t.body.Execute(&buf, notification); err != nil {
+		return "", "", fmt.Errorf("error while rendering body: %w", err)
+	}
+
+	return subject, buf.String(), nil
+}
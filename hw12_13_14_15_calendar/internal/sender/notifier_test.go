@@ -0,0 +1,219 @@
+package sender
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+var testNotification = models.Notification{
+	ID:               "a7e4e5a4-5d4e-4c3e-9a59-0f4f1b0b1d7e",
+	EventHeader:      "standup",
+	EventTime:        time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC),
+	UserID:           "user",
+	NotificationTime: time.Date(2024, time.January, 10, 9, 50, 0, 0, time.UTC),
+}
+
+func newTemplate(t *testing.T) *Template {
+	t.Helper()
+	template, err := NewTemplate(DefaultSubject, DefaultBody)
+	require.NoError(t, err)
+
+	return template
+}
+
+func TestTemplate(t *testing.T) {
+	subject, body, err := newTemplate(t).Render(testNotification)
+	require.NoError(t, err)
+	require.Equal(t, "Reminder: standup", subject)
+	require.Equal(t, "standup starts at 2024-01-10 10:00 UTC.", body)
+
+	_, err = NewTemplate("{{.EventHeader", DefaultBody)
+	require.Error(t, err)
+
+	template, err := NewTemplate("{{.Missing}}", DefaultBody)
+	require.NoError(t, err)
+	_, _, err = template.Render(testNotification)
+	require.Error(t, err)
+}
+
+func TestRouter(t *testing.T) {
+	var log, preferred bytes.Buffer
+	router := NewRouter(map[string]Notifier{
+		"log":       NewLogNotifier(&log, newTemplate(t)),
+		"preferred": NewLogNotifier(&preferred, newTemplate(t)),
+	}, map[string]Preference{
+		"user":     {Channel: "preferred"},
+		"stranger": {Channel: "pigeon"},
+	}, Preference{Channel: "log"})
+
+	require.NoError(t, router.Notify(context.Background(), testNotification))
+	require.Equal(t, testNotification.Key()+"\tuser\tReminder: standup: standup starts at 2024-01-10 10:00 UTC.\n",
+		preferred.String())
+	require.Empty(t, log.String())
+
+	other := testNotification
+	other.UserID = "other"
+	require.NoError(t, router.Notify(context.Background(), other))
+	require.Contains(t, log.String(), "\tother\t")
+
+	other.UserID = "stranger"
+	require.ErrorIs(t, router.Notify(context.Background(), other), ErrUnknownChannel)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL+"/default", "secret", time.Second, newTemplate(t))
+	require.NoError(t, notifier.Notify(context.Background(), "", testNotification))
+	require.Equal(t, http.MethodPost, request.Method)
+	require.Equal(t, "/default", request.URL.Path)
+	require.Equal(t, testNotification.Key(), request.Header.Get(IdempotencyKeyHeader))
+	require.Equal(t, Sign([]byte("secret"), body), request.Header.Get(SignatureHeader))
+	require.NotEqual(t, Sign([]byte("other"), body), request.Header.Get(SignatureHeader))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Equal(t, testNotification.ID, payload["eventId"])
+	require.Equal(t, "Reminder: standup", payload["subject"])
+	require.Equal(t, "standup starts at 2024-01-10 10:00 UTC.", payload["text"])
+
+	require.NoError(t, notifier.Notify(context.Background(), server.URL+"/user", testNotification))
+	require.Equal(t, "/user", request.URL.Path)
+
+	status = http.StatusInternalServerError
+	require.Error(t, notifier.Notify(context.Background(), "", testNotification))
+
+	require.ErrorIs(t, NewWebhookNotifier("", "secret", time.Second, newTemplate(t)).
+		Notify(context.Background(), "", testNotification), ErrNoAddress)
+}
+
+func TestEmailNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	mails := make(chan mail, 1)
+	go serveSMTP(listener, mails)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	notifier := NewEmailNotifier(host, port, "", "", "calendar@localhost", newTemplate(t))
+	require.NoError(t, notifier.Notify(context.Background(), "user@example.com", testNotification))
+
+	received := <-mails
+	require.Equal(t, "<calendar@localhost>", received.from)
+	require.Equal(t, []string{"<user@example.com>"}, received.to)
+	require.Contains(t, received.data, "Subject: Reminder: standup\r\n")
+	require.Contains(t, received.data, "X-Idempotency-Key: "+testNotification.Key()+"\r\n")
+	require.True(t, strings.HasSuffix(received.data, "\r\n\r\nstandup starts at 2024-01-10 10:00 UTC.\r\n"))
+
+	require.ErrorIs(t, notifier.Notify(context.Background(), "", testNotification), ErrNoAddress)
+}
+
+func TestEmailNotifierHeaders(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	mails := make(chan mail, 1)
+	go serveSMTP(listener, mails)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	notifier := NewEmailNotifier(host, port, "", "", "calendar@localhost", newTemplate(t))
+	injected := testNotification
+	injected.EventHeader = "планёрка\r\nBcc: x@y"
+	require.NoError(t, notifier.Notify(context.Background(), "user@example.com", injected))
+
+	received := <-mails
+	require.Equal(t, []string{"<user@example.com>"}, received.to)
+	headers := received.data[:strings.Index(received.data, "\r\n\r\n")]
+	require.NotContains(t, headers, "\r\nBcc:")
+	subject := headers[strings.Index(headers, "Subject: ")+len("Subject: "):]
+	subject = subject[:strings.Index(subject, "\r\n")]
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	require.NoError(t, err)
+	require.Equal(t, "Reminder: планёрка Bcc: x@y", decoded)
+}
+
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// serveSMTP accepts one SMTP session and sends the received mail to the channel.
+func serveSMTP(listener net.Listener, mails chan<- mail) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	var received mail
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			received.from = strings.TrimPrefix(command, "MAIL FROM:")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			received.to = append(received.to, strings.TrimPrefix(command, "RCPT TO:"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			received.data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			mails <- received
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
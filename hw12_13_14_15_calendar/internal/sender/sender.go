@@ -3,14 +3,17 @@ package sender
 //nolint:depguard
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/mb"
 )
 
 type Sender struct {
 	logger    Logger
 	consumer  mb.ConsumerMB
+	ledger    Ledger
+	router    *Router
 	routeKey  string
 	queueName string
 }
@@ -23,13 +26,65 @@ type Logger interface {
 	Fatal(msg string, fields map[string]interface{})
 }
 
-func New(logger Logger, consumer mb.ConsumerMB, queueName, routeKey string) *Sender {
-	return &Sender{logger: logger, consumer: consumer, routeKey: routeKey, queueName: queueName}
+// Ledger remembers delivered notifications, so a notification published twice is delivered once.
+// A sender claims a notification before delivering it, of senders handling its copies only one gets the claim.
+type Ledger interface {
+	ClaimNotification(ctx context.Context, notification models.Notification) (bool, error)
+	ReleaseNotification(ctx context.Context, notification models.Notification) error
+	MarkNotificationDelivered(ctx context.Context, notification models.Notification) (bool, error)
+}
+
+func New(logger Logger, consumer mb.ConsumerMB, ledger Ledger, router *Router, queueName, routeKey string) *Sender {
+	return &Sender{
+		logger: logger, consumer: consumer, ledger: ledger, router: router, routeKey: routeKey, queueName: queueName,
+	}
 }
 
 func (s *Sender) Start(ctx context.Context) {
-	s.consumer.ListenQueue(ctx, s.queueName, s.routeKey, func(msg []byte) bool {
-		fmt.Println("Notification: ", string(msg))
-		return true
+	s.consumer.ListenQueue(ctx, s.queueName, s.routeKey, func(key string, msg []byte) bool {
+		return s.handle(ctx, key, msg)
 	})
 }
+
+func (s *Sender) handle(ctx context.Context, key string, msg []byte) bool {
+	var notification models.Notification
+	if err := json.Unmarshal(msg, &notification); err != nil {
+		s.logger.Error("error while unmarshaling notification", map[string]interface{}{"error": err, "key": key})
+		return false
+	}
+
+	// The ledger is keyed by the notification, a message with another key can not be deduplicated.
+	if key != notification.Key() {
+		s.logger.Error("rejecting notification, idempotency key does not match it",
+			map[string]interface{}{"key": key, "notification key": notification.Key()})
+		return false
+	}
+
+	claimed, err := s.ledger.ClaimNotification(ctx, notification)
+	if err != nil {
+		return false
+	}
+
+	if !claimed {
+		s.logger.Info("skipping notification delivered or being delivered",
+			map[string]interface{}{"key": notification.Key()})
+		return true
+	}
+
+	if err = s.router.Notify(ctx, notification); err != nil {
+		s.logger.Error("error while delivering notification",
+			map[string]interface{}{"error": err, "key": notification.Key(), "user": notification.UserID})
+		if err = s.ledger.ReleaseNotification(ctx, notification); err != nil {
+			s.logger.Error("error while releasing notification",
+				map[string]interface{}{"error": err, "key": notification.Key()})
+		}
+		return false
+	}
+
+	if _, err = s.ledger.MarkNotificationDelivered(ctx, notification); err != nil {
+		s.logger.Error("error while marking notification delivered",
+			map[string]interface{}{"error": err, "key": notification.Key()})
+	}
+
+	return true
+}
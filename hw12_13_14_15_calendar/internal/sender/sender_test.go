@@ -0,0 +1,65 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	memorystorage "github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandle(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := memorystorage.New(logg)
+	ctx := context.Background()
+	msg, err := json.Marshal(testNotification)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	router := NewRouter(map[string]Notifier{"log": NewLogNotifier(&out, newTemplate(t))}, nil, Preference{Channel: "log"})
+	notificationSender := New(logg, nil, storage, router, "queue", "route")
+
+	require.False(t, notificationSender.handle(ctx, "key", []byte("not a notification")))
+
+	// A message with a foreign idempotency key is rejected without delivering it.
+	require.False(t, notificationSender.handle(ctx, "key", msg))
+	require.Empty(t, out.String())
+
+	require.True(t, notificationSender.handle(ctx, testNotification.Key(), msg))
+	state, err := storage.GetNotificationState(ctx, testNotification)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationDelivered, state)
+	require.Equal(t, 1, strings.Count(out.String(), "\n"))
+
+	// A redelivered message is acknowledged without delivering the notification again.
+	require.True(t, notificationSender.handle(ctx, testNotification.Key(), msg))
+	require.Equal(t, 1, strings.Count(out.String(), "\n"))
+
+	// A notification claimed by another sender is acknowledged without delivering it.
+	claimed := testNotification
+	claimed.EventTime = claimed.EventTime.AddDate(0, 0, 2)
+	ok, err := storage.ClaimNotification(ctx, claimed)
+	require.NoError(t, err)
+	require.True(t, ok)
+	msg, err = json.Marshal(claimed)
+	require.NoError(t, err)
+	require.True(t, notificationSender.handle(ctx, claimed.Key(), msg))
+	require.Equal(t, 1, strings.Count(out.String(), "\n"))
+
+	// An undelivered notification is not marked delivered, its claim is released for a later delivery.
+	other := testNotification
+	other.EventTime = other.EventTime.AddDate(0, 0, 1)
+	other.UserID = "stranger"
+	router.preferences = map[string]Preference{"stranger": {Channel: "pigeon"}}
+	msg, err = json.Marshal(other)
+	require.NoError(t, err)
+	require.False(t, notificationSender.handle(ctx, other.Key(), msg))
+	state, err = storage.GetNotificationState(ctx, other)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationPublished, state)
+}
@@ -0,0 +1,96 @@
+package sender
+
+//nolint:depguard
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+)
+
+const (
+	// SignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of the request body keyed by the secret.
+	SignatureHeader      = "X-Calendar-Signature"
+	IdempotencyKeyHeader = "X-Idempotency-Key"
+)
+
+// WebhookNotifier posts notifications as JSON to an URL, the URL of the notifier is used
+// for users without their own one.
+type WebhookNotifier struct {
+	url      string
+	secret   []byte
+	client   *http.Client
+	template *Template
+}
+
+type webhookPayload struct {
+	models.Notification
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+func NewWebhookNotifier(url, secret string, timeout time.Duration, template *Template) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:      url,
+		secret:   []byte(secret),
+		client:   &http.Client{Timeout: timeout},
+		template: template,
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, address string, notification models.Notification) error {
+	if address == "" {
+		address = n.url
+	}
+
+	if address == "" {
+		return fmt.Errorf("%w: webhook of user %s", ErrNoAddress, notification.UserID)
+	}
+
+	subject, text, err := n.template.Render(notification)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(webhookPayload{Notification: notification, Subject: subject, Text: text})
+	if err != nil {
+		return fmt.Errorf("error while marshaling webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error while creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, notification.Key())
+	req.Header.Set(SignatureHeader, Sign(n.secret, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while calling webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the value of SignatureHeader for the body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
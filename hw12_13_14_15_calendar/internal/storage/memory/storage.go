@@ -4,6 +4,7 @@ package memorystorage
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,101 +15,322 @@ import (
 
 type Storage struct {
 	repository map[string]models.Event
-	logger     app.Logger
-	mu         sync.RWMutex
+	// index maps words of headers and descriptions to identifiers of events.
+	index map[string]map[string]struct{}
+	// audit is the log of changes of events in the order they were made.
+	audit []models.AuditEntry
+	// watchers are notified of revisions of new audit entries.
+	watchers map[chan int64]struct{}
+	// watermarks are the ends of the windows of notifications published by schedulers.
+	watermarks map[string]time.Time
+	// ledger maps keys of published and delivered notifications to their states.
+	ledger map[string]models.NotificationState
+	// outbox keeps messages of notifications in the order they were enqueued.
+	outbox []models.OutboxMessage
+	logger app.Logger
+	mu     sync.RWMutex
 }
 
 func New(logger app.Logger) *Storage {
 	repo := make(map[string]models.Event)
-	return &Storage{repository: repo, logger: logger, mu: sync.RWMutex{}}
+	index := make(map[string]map[string]struct{})
+	return &Storage{
+		repository: repo, index: index, watchers: make(map[chan int64]struct{}), watermarks: make(map[string]time.Time),
+		ledger: make(map[string]models.NotificationState), logger: logger, mu: sync.RWMutex{},
+	}
 }
 
 func (s *Storage) Close() {
 }
 
-func (s *Storage) CreateEvent(_ context.Context, eventDTO models.Event) (string, error) {
-	newUUID := uuid.New()
+func (s *Storage) CreateEvent(ctx context.Context, eventDTO models.Event) (string, error) {
 	s.mu.Lock()
-	s.repository[newUUID.String()] = eventDTO
-	s.mu.Unlock()
-	s.logger.Info("event was created", map[string]interface{}{"id": newUUID})
+	defer s.mu.Unlock()
+	event, err := s.createEvent(ctx, eventDTO)
+	if err != nil {
+		return "", err
+	}
+	s.notifyWatchers()
 
-	return newUUID.String(), nil
+	return event.ID, nil
 }
 
-func (s *Storage) UpdateEvent(_ context.Context, eventDTO models.Event) error {
-	if eventDTO.ID == "" {
-		s.logger.Error("event id is required parameter", nil)
-		return fmt.Errorf("event id is required parameter")
+func (s *Storage) UpdateEvent(ctx context.Context, eventDTO models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.updateEvent(ctx, eventDTO); err != nil {
+		return err
 	}
-	_, ok := s.repository[eventDTO.ID]
-	if !ok {
-		s.logger.Error("event with such an id is does not exist", map[string]interface{}{"id": eventDTO.ID})
-		return fmt.Errorf("event with such an id is does not exist")
+	s.notifyWatchers()
+
+	return nil
+}
+
+func (s *Storage) PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkAccess(ctx, id); err != nil {
+		return models.Event{}, err
 	}
 
+	if err := s.checkVersion(id, patch.Event.Version); err != nil {
+		return models.Event{}, err
+	}
+
+	event := s.repository[id]
+	if len(patch.Fields) == 0 {
+		event.ID = id
+		return event, nil
+	}
+
+	before := event
+	before.ID = id
+	event = patch.Apply(event)
+	if s.isDateBusy(event, id) {
+		s.logger.Error(app.ErrDateBusy.Error(), map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrDateBusy
+	}
+	event.Version++
+
+	s.unindexEvent(id)
+	s.repository[id] = event
+	s.indexEvent(id, event)
+	s.logger.Info("event was patched", map[string]interface{}{"id": id, "fields": patch.Fields})
+	event.ID = id
+	s.record(app.NewAuditEntry(ctx, models.AuditUpdate, &before, &event))
+	s.notifyWatchers()
+
+	return event, nil
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string, version int64) error {
 	s.mu.Lock()
-	s.repository[eventDTO.ID] = eventDTO
-	s.mu.Unlock()
-	s.logger.Info("event was updated", map[string]interface{}{"id": eventDTO.ID})
+	defer s.mu.Unlock()
+	if _, err := s.deleteEvent(ctx, id, version); err != nil {
+		return err
+	}
+	s.notifyWatchers()
 
 	return nil
 }
 
-func (s *Storage) DeleteEvent(_ context.Context, id string) error {
-	_, ok := s.repository[id]
-	if !ok {
-		s.logger.Error("event with such an id is does not exist", map[string]interface{}{"id": id})
-		return fmt.Errorf("event with such an id is does not exist")
+// ApplyBatch applies the items under a single lock, so nobody sees a part of an atomic batch.
+// An atomic batch stops at the first failed item and reverts the items applied before it with their audit entries.
+func (s *Storage) ApplyBatch(
+	ctx context.Context, items []models.BatchItem, atomic bool,
+) ([]models.BatchResult, error) {
+	results := make([]models.BatchResult, len(items))
+	applied := make([]batchUndo, 0, len(items))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revision := len(s.audit)
+	for i, item := range items {
+		previous, existed := s.repository[item.Event.ID]
+
+		var event models.Event
+		var err error
+		switch item.Operation {
+		case models.BatchCreate:
+			existed = false
+			event, err = s.createEvent(ctx, item.Event)
+		case models.BatchUpdate:
+			event, err = s.updateEvent(ctx, item.Event)
+		case models.BatchDelete:
+			event, err = s.deleteEvent(ctx, item.Event.ID, item.Event.Version)
+		default:
+			err = fmt.Errorf("%w: unknown batch operation %q", app.ErrInvalidArgument, item.Operation)
+		}
+
+		results[i] = models.BatchResult{ID: event.ID, Event: event, Err: err}
+		if err == nil {
+			applied = append(applied, batchUndo{id: event.ID, previous: previous, existed: existed})
+			continue
+		}
+
+		if atomic {
+			s.revert(applied)
+			s.audit = s.audit[:revision]
+			s.logger.Info("batch was rolled back", map[string]interface{}{"failedItem": i, "error": err})
+			return results, nil
+		}
 	}
 
-	s.mu.Lock()
-	delete(s.repository, id)
-	s.mu.Unlock()
-	s.logger.Info("event was deleted", map[string]interface{}{"id": id})
+	if len(s.audit) > revision {
+		s.notifyWatchers()
+	}
 
-	return nil
+	return results, nil
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkAccess(ctx, id); err != nil {
+		return models.Event{}, err
+	}
+
+	event := s.repository[id]
+	event.ID = id
+
+	return event, nil
+}
+
+// GetListEventsDuringDay returns events of the day in the location of targetDay.
+func (s *Storage) GetListEventsDuringDay(ctx context.Context, targetDay time.Time) ([]models.Event, error) {
+	from, to := app.DaysBounds(targetDay, 1)
+
+	return s.GetListEventsInRange(ctx, from, to)
 }
 
-func (s *Storage) GetListEventsDuringDay(_ context.Context, targetDay time.Time) ([]models.Event, error) {
+func (s *Storage) GetListEventsDuringFewDays(
+	ctx context.Context, start time.Time, amountDays int,
+) ([]models.Event, error) {
+	from, to := app.DaysBounds(start, amountDays)
+
+	return s.GetListEventsInRange(ctx, from, to)
+}
+
+func (s *Storage) GetListEventsInRange(ctx context.Context, from, to time.Time) ([]models.Event, error) {
 	events := make([]models.Event, 0)
 	s.mu.RLock()
+	defer s.mu.RUnlock()
 	for id, event := range s.repository {
-		if event.EventTime.Before(targetDay.Add(24*time.Hour).Truncate(24*time.Hour)) &&
-			targetDay.Before(event.EventTime.Add(24*time.Hour).Truncate(24*time.Hour)) {
-			event.ID = id
-			events = append(events, event)
+		if !isAccessible(ctx, event) || event.DeletedAt != nil {
+			continue
 		}
+
+		event.ID = id
+		occurrences, err := event.Occurrences(from, to)
+		if err != nil {
+			s.logger.Error("error while expanding recurring event", map[string]interface{}{"error": err, "id": id})
+			return nil, err
+		}
+
+		events = append(events, occurrences...)
 	}
-	s.mu.RUnlock()
+	models.SortEvents(events)
 
 	return events, nil
 }
 
-func (s *Storage) GetListEventsDuringFewDays(
-	_ context.Context, start time.Time, amountDays int,
+func (s *Storage) GetListEventsPage(
+	ctx context.Context, from, to time.Time, after *models.Cursor, limit int,
 ) ([]models.Event, error) {
+	events, err := s.GetListEventsInRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	page := make([]models.Event, 0, limit)
+	for _, event := range events {
+		if len(page) == limit {
+			break
+		}
+
+		if after == nil || after.Precedes(event) {
+			page = append(page, event)
+		}
+	}
+
+	return page, nil
+}
+
+func (s *Storage) SearchEvents(ctx context.Context, query models.SearchQuery) ([]models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	terms := models.SearchTerms(query.Text)
+	events := make([]models.Event, 0)
+	if len(terms) == 0 {
+		return events, nil
+	}
+
+	for id := range s.index[terms[0]] {
+		event := s.repository[id]
+		if !isAccessible(ctx, event) || event.DeletedAt != nil || !query.Matches(event) ||
+			!s.containsTerms(id, terms[1:]) {
+			continue
+		}
+
+		event.ID = id
+		events = append(events, event)
+	}
+	models.SortEvents(events)
+
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+
+	return events, nil
+}
+
+// GetListDeletedEvents returns the trash, the most recently deleted events go first.
+func (s *Storage) GetListDeletedEvents(ctx context.Context) ([]models.Event, error) {
 	events := make([]models.Event, 0)
 	s.mu.RLock()
+	defer s.mu.RUnlock()
 	for id, event := range s.repository {
-		startDay := start.Truncate(24 * time.Hour)
-		finishDay := startDay.AddDate(0, 0, amountDays).Truncate(24 * time.Hour)
-
-		if event.EventTime.After(startDay) && event.EventTime.Before(finishDay) {
-			event.ID = id
-			events = append(events, event)
+		if !isAccessible(ctx, event) || event.DeletedAt == nil {
+			continue
 		}
+
+		event.ID = id
+		events = append(events, event)
 	}
-	s.mu.RUnlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].DeletedAt.Equal(*events[j].DeletedAt) {
+			return events[i].DeletedAt.After(*events[j].DeletedAt)
+		}
+
+		return events[i].ID < events[j].ID
+	})
 
 	return events, nil
 }
 
-func (s *Storage) DeleteOldEvent(_ context.Context) error {
+func (s *Storage) RestoreEvent(ctx context.Context, id string) (models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.repository[id]
+	if !ok {
+		s.logger.Error("event with such an id is does not exist", map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrEventNotFound
+	}
+
+	if !isAccessible(ctx, event) {
+		s.logger.Error("event belongs to another user", map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrEventForbidden
+	}
+
+	if event.DeletedAt == nil {
+		s.logger.Error(app.ErrEventNotDeleted.Error(), map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrEventNotDeleted
+	}
+
+	event.DeletedAt = nil
+	if s.isDateBusy(event, id) {
+		s.logger.Error(app.ErrDateBusy.Error(), map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrDateBusy
+	}
+
+	event.Version++
+	s.repository[id] = event
+	s.logger.Info("event was restored", map[string]interface{}{"id": id})
+	event.ID = id
+	s.record(app.NewAuditEntry(ctx, models.AuditRestore, nil, &event))
+	s.notifyWatchers()
+
+	return event, nil
+}
+
+// PurgeDeletedEvents permanently removes events which were moved to the trash before deletedBefore.
+func (s *Storage) PurgeDeletedEvents(_ context.Context, deletedBefore time.Time) error {
 	var count int
 	s.mu.Lock()
 	for id, event := range s.repository {
-		if event.EventTime.Before(time.Now().AddDate(-1, 0, 0)) {
+		if event.DeletedAt != nil && event.DeletedAt.Before(deletedBefore) {
+			s.unindexEvent(id)
 			delete(s.repository, id)
 			count++
 		}
@@ -116,29 +338,477 @@ func (s *Storage) DeleteOldEvent(_ context.Context) error {
 	s.mu.Unlock()
 
 	if count > 0 {
-		s.logger.Info(fmt.Sprintf("%d events have been deleted", count), nil)
+		s.logger.Info(fmt.Sprintf("%d events have been purged", count), nil)
 	}
 
 	return nil
 }
 
-func (s *Storage) GetNotifications(_ context.Context) ([]models.Notification, error) {
-	var notifications []models.Notification
+func (s *Storage) GetEventHistory(ctx context.Context, eventID string) ([]models.AuditEntry, error) {
+	entries := make([]models.AuditEntry, 0)
+	userID, scoped := app.UserIDFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, entry := range s.audit {
+		if entry.EventID != eventID || scoped && entry.UserID != userID {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (s *Storage) GetChanges(ctx context.Context, afterRevision int64, limit int) ([]models.AuditEntry, error) {
+	entries := make([]models.AuditEntry, 0)
+	userID, scoped := app.UserIDFromContext(ctx)
 	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// Revisions are positions in the log starting from one.
+	for i := int(max(afterRevision, 0)); i < len(s.audit) && len(entries) < limit; i++ {
+		if scoped && s.audit[i].UserID != userID {
+			continue
+		}
+
+		entries = append(entries, s.audit[i])
+	}
+
+	return entries, nil
+}
+
+func (s *Storage) GetRevision(_ context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.audit)), nil
+}
+
+func (s *Storage) WatchRevisions(ctx context.Context) (<-chan int64, error) {
+	revisions := make(chan int64, 1)
+	s.mu.Lock()
+	s.watchers[revisions] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.watchers, revisions)
+		close(revisions)
+		s.mu.Unlock()
+	}()
+
+	return revisions, nil
+}
+
+func (s *Storage) GetNotifications(_ context.Context, from, to time.Time) ([]models.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.notifications(from, to)
+}
+
+// notifications returns notifications due within [from, to), the caller must hold the lock.
+func (s *Storage) notifications(from, to time.Time) ([]models.Notification, error) {
+	notifications := make([]models.Notification, 0)
 	for id, event := range s.repository {
-		if event.EventTime.Before(time.Now().Add(24*time.Hour).Truncate(24*time.Hour)) &&
-			time.Now().Before(event.EventTime.Add(24*time.Hour).Truncate(24*time.Hour)) {
-			notification := models.Notification{
-				ID:          id,
-				EventHeader: event.Header,
-				EventTime:   event.EventTime,
-				UserID:      event.UserID,
-			}
+		if event.DeletedAt != nil {
+			continue
+		}
 
-			notifications = append(notifications, notification)
+		event.ID = id
+		eventNotifications, err := event.NotificationsIn(from, to)
+		if err != nil {
+			s.logger.Error("error while expanding recurring event", map[string]interface{}{"error": err, "id": id})
+			return nil, err
 		}
+
+		notifications = append(notifications, eventNotifications...)
 	}
-	s.mu.RUnlock()
+	models.SortNotifications(notifications)
 
 	return notifications, nil
 }
+
+func (s *Storage) GetWatermark(_ context.Context, name string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.watermarks[name], nil
+}
+
+func (s *Storage) SetWatermark(_ context.Context, name string, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watermarks[name] = watermark
+
+	return nil
+}
+
+func (s *Storage) GetNotificationState(
+	_ context.Context, notification models.Notification,
+) (models.NotificationState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ledger[notification.Key()], nil
+}
+
+func (s *Storage) MarkNotificationPublished(_ context.Context, notification models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ledger[notification.Key()]; !ok {
+		s.ledger[notification.Key()] = models.NotificationPublished
+	}
+
+	return nil
+}
+
+func (s *Storage) ClaimNotification(_ context.Context, notification models.Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.ledger[notification.Key()]
+	if state == models.NotificationDelivering || state == models.NotificationDelivered {
+		return false, nil
+	}
+	s.ledger[notification.Key()] = models.NotificationDelivering
+
+	return true, nil
+}
+
+func (s *Storage) ReleaseNotification(_ context.Context, notification models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ledger[notification.Key()] == models.NotificationDelivering {
+		s.ledger[notification.Key()] = models.NotificationPublished
+	}
+
+	return nil
+}
+
+func (s *Storage) MarkNotificationDelivered(_ context.Context, notification models.Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ledger[notification.Key()] == models.NotificationDelivered {
+		return false, nil
+	}
+	s.ledger[notification.Key()] = models.NotificationDelivered
+
+	return true, nil
+}
+
+func (s *Storage) EnqueueNotifications(_ context.Context, watermarkName string, from, to time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notifications, err := s.notifications(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for _, notification := range notifications {
+		if _, ok := s.ledger[notification.Key()]; ok || s.isEnqueued(notification) {
+			continue
+		}
+
+		now := time.Now()
+		s.outbox = append(s.outbox, models.OutboxMessage{
+			ID:           int64(len(s.outbox) + 1),
+			Notification: notification,
+			NextAttempt:  now,
+			CreatedAt:    now,
+		})
+		enqueued++
+	}
+	s.watermarks[watermarkName] = to
+
+	return enqueued, nil
+}
+
+func (s *Storage) GetOutbox(_ context.Context, now time.Time, limit int) ([]models.OutboxMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	messages := make([]models.OutboxMessage, 0)
+	for _, message := range s.outbox {
+		if len(messages) == limit {
+			break
+		}
+
+		if message.SentAt == nil && !message.NextAttempt.After(now) {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *Storage) MarkOutboxSent(_ context.Context, message models.OutboxMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.outbox[message.ID-1].SentAt = &now
+	if _, ok := s.ledger[message.Notification.Key()]; !ok {
+		s.ledger[message.Notification.Key()] = models.NotificationPublished
+	}
+
+	return nil
+}
+
+func (s *Storage) MarkOutboxFailed(_ context.Context, id int64, nextAttempt time.Time, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox[id-1].Attempts++
+	s.outbox[id-1].NextAttempt = nextAttempt
+	s.outbox[id-1].LastError = reason
+
+	return nil
+}
+
+func (s *Storage) GetOutboxStats(_ context.Context, stuckBefore time.Time) (models.OutboxStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var stats models.OutboxStats
+	for _, message := range s.outbox {
+		if message.SentAt != nil {
+			continue
+		}
+
+		stats.Pending++
+		if message.CreatedAt.Before(stuckBefore) {
+			stats.Stuck++
+		}
+
+		if stats.Oldest.IsZero() || message.CreatedAt.Before(stats.Oldest) {
+			stats.Oldest = message.CreatedAt
+		}
+	}
+
+	return stats, nil
+}
+
+// isEnqueued reports whether the outbox has the notification, the caller must hold the lock.
+func (s *Storage) isEnqueued(notification models.Notification) bool {
+	for _, message := range s.outbox {
+		if message.Notification.Key() == notification.Key() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// record appends the audit entry of a change to the log, the caller must hold the lock.
+// Watchers learn of it from notifyWatchers once the change can not be reverted anymore.
+func (s *Storage) record(entry models.AuditEntry) {
+	entry.ID = int64(len(s.audit) + 1)
+	entry.Before, entry.After = copyEvent(entry.Before), copyEvent(entry.After)
+	s.audit = append(s.audit, entry)
+}
+
+// notifyWatchers reports the latest revision to every watcher, the caller must hold the lock.
+func (s *Storage) notifyWatchers() {
+	for watcher := range s.watchers {
+		select {
+		case watcher <- int64(len(s.audit)):
+		default:
+		}
+	}
+}
+
+// createEvent stores a new event, the caller must hold the lock.
+func (s *Storage) createEvent(ctx context.Context, eventDTO models.Event) (models.Event, error) {
+	if s.isDateBusy(eventDTO, "") {
+		s.logger.Error(app.ErrDateBusy.Error(), map[string]interface{}{"eventTime": eventDTO.EventTime})
+		return models.Event{}, app.ErrDateBusy
+	}
+
+	id := uuid.New().String()
+	eventDTO.ID, eventDTO.Version, eventDTO.DeletedAt = "", 1, nil
+	s.repository[id] = eventDTO
+	s.indexEvent(id, eventDTO)
+	s.logger.Info("event was created", map[string]interface{}{"id": id})
+	eventDTO.ID = id
+	s.record(app.NewAuditEntry(ctx, models.AuditCreate, nil, &eventDTO))
+
+	return eventDTO, nil
+}
+
+// updateEvent replaces the stored event, the caller must hold the lock.
+func (s *Storage) updateEvent(ctx context.Context, eventDTO models.Event) (models.Event, error) {
+	if eventDTO.ID == "" {
+		s.logger.Error("event id is required parameter", nil)
+		return models.Event{}, fmt.Errorf("%w: event id is required parameter", app.ErrInvalidArgument)
+	}
+
+	if err := s.checkAccess(ctx, eventDTO.ID); err != nil {
+		return models.Event{}, err
+	}
+
+	if err := s.checkVersion(eventDTO.ID, eventDTO.Version); err != nil {
+		return models.Event{}, err
+	}
+
+	if s.isDateBusy(eventDTO, eventDTO.ID) {
+		s.logger.Error(app.ErrDateBusy.Error(), map[string]interface{}{"id": eventDTO.ID})
+		return models.Event{}, app.ErrDateBusy
+	}
+
+	before := s.repository[eventDTO.ID]
+	before.ID = eventDTO.ID
+	eventDTO.Version, eventDTO.DeletedAt = before.Version+1, nil
+	s.unindexEvent(eventDTO.ID)
+	s.repository[eventDTO.ID] = eventDTO
+	s.indexEvent(eventDTO.ID, eventDTO)
+	s.logger.Info("event was updated", map[string]interface{}{"id": eventDTO.ID})
+	s.record(app.NewAuditEntry(ctx, models.AuditUpdate, &before, &eventDTO))
+
+	return eventDTO, nil
+}
+
+// deleteEvent moves the event to the trash, the caller must hold the lock.
+func (s *Storage) deleteEvent(ctx context.Context, id string, version int64) (models.Event, error) {
+	if err := s.checkAccess(ctx, id); err != nil {
+		return models.Event{}, err
+	}
+
+	if err := s.checkVersion(id, version); err != nil {
+		return models.Event{}, err
+	}
+
+	event := s.repository[id]
+	before := event
+	before.ID = id
+	deletedAt := time.Now()
+	event.DeletedAt = &deletedAt
+	event.Version++
+	s.repository[id] = event
+	s.logger.Info("event was moved to the trash", map[string]interface{}{"id": id})
+	event.ID = id
+	s.record(app.NewAuditEntry(ctx, models.AuditDelete, &before, nil))
+
+	return event, nil
+}
+
+// batchUndo is the state of an event before a batch item changed it.
+type batchUndo struct {
+	id       string
+	previous models.Event
+	existed  bool
+}
+
+// revert restores the events changed by the applied batch items in reverse order, the caller must hold the lock.
+func (s *Storage) revert(applied []batchUndo) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		undo := applied[i]
+		s.unindexEvent(undo.id)
+		if !undo.existed {
+			delete(s.repository, undo.id)
+			continue
+		}
+
+		s.repository[undo.id] = undo.previous
+		s.indexEvent(undo.id, undo.previous)
+	}
+}
+
+// checkAccess verifies that the event exists and is visible to the caller, the caller must hold the lock.
+func (s *Storage) checkAccess(ctx context.Context, id string) error {
+	event, ok := s.repository[id]
+	if !ok || event.DeletedAt != nil {
+		s.logger.Error("event with such an id is does not exist", map[string]interface{}{"id": id})
+		return app.ErrEventNotFound
+	}
+
+	if !isAccessible(ctx, event) {
+		s.logger.Error("event belongs to another user", map[string]interface{}{"id": id})
+		return app.ErrEventForbidden
+	}
+
+	return nil
+}
+
+// checkVersion compares a non-zero expected version with the stored one, the caller must hold the lock.
+func (s *Storage) checkVersion(id string, version int64) error {
+	if version != 0 && s.repository[id].Version != version {
+		s.logger.Error(app.ErrVersionMismatch.Error(), map[string]interface{}{"id": id, "version": version})
+		return app.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// indexEvent adds words of the event to the search index, the caller must hold the lock.
+func (s *Storage) indexEvent(id string, event models.Event) {
+	for _, term := range models.SearchTerms(event.Header + " " + event.Description) {
+		ids, ok := s.index[term]
+		if !ok {
+			ids = make(map[string]struct{})
+			s.index[term] = ids
+		}
+
+		ids[id] = struct{}{}
+	}
+}
+
+// unindexEvent removes the stored event from the search index, the caller must hold the lock.
+func (s *Storage) unindexEvent(id string) {
+	event, ok := s.repository[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range models.SearchTerms(event.Header + " " + event.Description) {
+		delete(s.index[term], id)
+		if len(s.index[term]) == 0 {
+			delete(s.index, term)
+		}
+	}
+}
+
+func (s *Storage) containsTerms(id string, terms []string) bool {
+	for _, term := range terms {
+		if _, ok := s.index[term][id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isDateBusy scans the time slots of the event owner for an overlap, the caller must hold the lock.
+func (s *Storage) isDateBusy(eventDTO models.Event, excludeID string) bool {
+	if !occupiesTimeSlot(eventDTO) {
+		return false
+	}
+
+	for id, event := range s.repository {
+		if id == excludeID || event.UserID != eventDTO.UserID || event.DeletedAt != nil || !occupiesTimeSlot(event) {
+			continue
+		}
+
+		if eventDTO.EventTime.Before(*event.FinishEventTime) && event.EventTime.Before(*eventDTO.FinishEventTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func occupiesTimeSlot(event models.Event) bool {
+	return event.FinishEventTime != nil && !event.IsRecurring()
+}
+
+// isAccessible reports whether the event belongs to the user from the context, if there is one.
+func isAccessible(ctx context.Context, event models.Event) bool {
+	userID, ok := app.UserIDFromContext(ctx)
+	return !ok || event.UserID == userID
+}
+
+func copyEvent(event *models.Event) *models.Event {
+	if event == nil {
+		return nil
+	}
+
+	eventCopy := *event
+	return &eventCopy
+}
@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/storage/storagetest"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -99,6 +101,31 @@ func TestUpdateEvent(t *testing.T) {
 	require.Equal(t, eventFromStorageNew.Header, newHeader)
 }
 
+func TestGetEvent(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := New(logg)
+	ownerCtx := app.ContextWithUserID(context.Background(), "owner")
+
+	id, err := storage.CreateEvent(ownerCtx, models.Event{
+		Header:    testEvents[0].Header,
+		UserID:    "owner",
+		EventTime: testEvents[0].EventTime,
+	})
+	require.NoError(t, err)
+
+	event, err := storage.GetEvent(ownerCtx, id)
+	require.NoError(t, err)
+	require.Equal(t, id, event.ID)
+	require.Equal(t, testEvents[0].Header, event.Header)
+
+	_, err = storage.GetEvent(app.ContextWithUserID(context.Background(), "stranger"), id)
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+
+	_, err = storage.GetEvent(ownerCtx, uuid.New().String())
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+}
+
 func TestGetEvents(t *testing.T) {
 	logg, err := logger.GetLogger("INFO")
 	require.NoError(t, err)
@@ -124,31 +151,69 @@ func TestGetEvents(t *testing.T) {
 	require.Equal(t, len(eventsPerMonth), 10)
 }
 
-func TestDeleteOldEvent(t *testing.T) {
+func TestGetListEventsInRange(t *testing.T) {
 	logg, err := logger.GetLogger("INFO")
 	require.NoError(t, err)
 	storage := New(logg)
 	ctx := context.Background()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
 
-	_, err = storage.CreateEvent(ctx, models.Event{
-		Header:    "old event",
-		EventTime: time.Now().AddDate(-1, 0, -1),
-	})
+	// The week of the switch to summer time lasts 167 hours in Berlin.
+	from, to := app.WeekBounds(time.Date(2024, time.March, 27, 0, 0, 0, 0, berlin), time.Monday)
+	for _, event := range []models.Event{
+		{Header: "first minute", EventTime: time.Date(2024, time.March, 24, 23, 0, 0, 0, time.UTC)},
+		{Header: "last minute", EventTime: time.Date(2024, time.March, 31, 23, 59, 0, 0, berlin)},
+		{Header: "next week", EventTime: time.Date(2024, time.April, 1, 0, 0, 0, 0, berlin)},
+		{Header: "previous week", EventTime: time.Date(2024, time.March, 24, 23, 59, 0, 0, berlin)},
+		{
+			Header:     "daily",
+			EventTime:  time.Date(2024, time.March, 20, 9, 0, 0, 0, berlin),
+			Recurrence: "FREQ=DAILY",
+		},
+	} {
+		_, err := storage.CreateEvent(ctx, event)
+		require.NoError(t, err)
+	}
+
+	events, err := storage.GetListEventsInRange(ctx, from, to)
 	require.NoError(t, err)
 
-	eventsBefore, err := storage.GetListEventsDuringFewDays(ctx, time.Now().AddDate(-1, 0, -2), 367)
+	headers := make(map[string]int)
+	for _, event := range events {
+		headers[event.Header]++
+	}
+	require.Equal(t, map[string]int{"first minute": 1, "last minute": 1, "daily": 7}, headers)
+}
+
+func TestPurgeDeletedEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
 	require.NoError(t, err)
-	countBefore := len(eventsBefore)
+	storage := New(logg)
+	ctx := context.Background()
 
-	err = storage.DeleteOldEvent(ctx)
+	oldID, err := storage.CreateEvent(ctx, models.Event{Header: "deleted long ago", EventTime: time.Now()})
 	require.NoError(t, err)
+	recentID, err := storage.CreateEvent(ctx, models.Event{Header: "deleted recently", EventTime: time.Now()})
+	require.NoError(t, err)
+	_, err = storage.CreateEvent(ctx, models.Event{Header: "old event", EventTime: time.Now().AddDate(-2, 0, 0)})
+	require.NoError(t, err)
+
+	require.NoError(t, storage.DeleteEvent(ctx, oldID, 0))
+	deletedAt := time.Now().AddDate(0, 0, -31)
+	event := storage.repository[oldID]
+	event.DeletedAt = &deletedAt
+	storage.repository[oldID] = event
+	require.NoError(t, storage.DeleteEvent(ctx, recentID, 0))
 
-	eventsAfter, err := storage.GetListEventsDuringFewDays(ctx, time.Now().AddDate(-1, 0, -2), 367)
+	err = storage.PurgeDeletedEvents(ctx, time.Now().AddDate(0, 0, -30))
 	require.NoError(t, err)
-	countAfter := len(eventsAfter)
 
-	require.Equal(t, 1, countBefore)
-	require.Equal(t, 0, countAfter)
+	trash, err := storage.GetListDeletedEvents(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, recentID, trash[0].ID)
+	require.Len(t, storage.repository, 2)
 }
 
 func TestGetNotifications(t *testing.T) {
@@ -159,23 +224,175 @@ func TestGetNotifications(t *testing.T) {
 	id, err := uuid.NewUUID()
 	require.NoError(t, err)
 
+	now := time.Now()
 	testNotification := models.Notification{
-		EventHeader: "testEvent",
-		EventTime:   time.Now(),
-		UserID:      id.String(),
+		EventHeader:      "testEvent",
+		EventTime:        now.Add(10 * time.Minute),
+		UserID:           id.String(),
+		NotificationTime: now,
 	}
 
 	eventID, err := storage.CreateEvent(ctx, models.Event{
-		Header:    testNotification.EventHeader,
-		UserID:    testNotification.UserID,
-		EventTime: testNotification.EventTime,
-	})
+		Header:       testNotification.EventHeader,
+		UserID:       testNotification.UserID,
+		EventTime:    testNotification.EventTime,
+		RemindBefore: models.Duration(10 * time.Minute),
+	}.WithReminder())
 	require.NoError(t, err)
 	testNotification.ID = eventID
 
-	notifications, err := storage.GetNotifications(ctx)
+	notifications, err := storage.GetNotifications(ctx, now.Add(-time.Minute), now.Add(time.Minute))
 	require.NoError(t, err)
 
 	require.Equal(t, 1, len(notifications))
 	require.Equal(t, testNotification, notifications[0])
 }
+
+func TestGetRecurringEvents(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := New(logg)
+	ctx := context.Background()
+
+	start := time.Now().Truncate(24 * time.Hour).Add(10 * time.Hour)
+	finish := start.Add(time.Hour)
+	notification := start.Add(-time.Hour)
+	_, err = storage.CreateEvent(ctx, models.Event{
+		Header:           "standup",
+		EventTime:        start,
+		FinishEventTime:  &finish,
+		NotificationTime: &notification,
+		Recurrence:       "FREQ=DAILY;COUNT=5",
+		ExDates:          []time.Time{start.AddDate(0, 0, 2)},
+	})
+	require.NoError(t, err)
+
+	eventsPerDay, err := storage.GetListEventsDuringDay(ctx, start.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(eventsPerDay))
+	require.Equal(t, start.AddDate(0, 0, 1), eventsPerDay[0].EventTime)
+	require.Equal(t, finish.AddDate(0, 0, 1), *eventsPerDay[0].FinishEventTime)
+
+	eventsPerExDate, err := storage.GetListEventsDuringDay(ctx, start.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(eventsPerExDate))
+
+	eventsPerWeek, err := storage.GetListEventsDuringFewDays(ctx, start, 7)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(eventsPerWeek))
+
+	notifications, err := storage.GetNotifications(ctx, start.Add(-2*time.Hour), start.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(notifications))
+	require.Equal(t, start, notifications[0].EventTime)
+	require.Equal(t, notification.AddDate(0, 0, 1), notifications[1].NotificationTime)
+}
+
+func TestUserScoping(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := New(logg)
+	ownerCtx := app.ContextWithUserID(context.Background(), "owner")
+	strangerCtx := app.ContextWithUserID(context.Background(), "stranger")
+
+	id, err := storage.CreateEvent(ownerCtx, models.Event{
+		Header:    "private",
+		UserID:    "owner",
+		EventTime: time.Now(),
+	})
+	require.NoError(t, err)
+
+	ownerEvents, err := storage.GetListEventsDuringDay(ownerCtx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ownerEvents))
+
+	strangerEvents, err := storage.GetListEventsDuringDay(strangerCtx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, len(strangerEvents))
+
+	err = storage.UpdateEvent(strangerCtx, models.Event{ID: id, Header: "stolen", UserID: "stranger"})
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+
+	err = storage.DeleteEvent(strangerCtx, id, 0)
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+
+	err = storage.DeleteEvent(ownerCtx, uuid.New().String(), 0)
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+
+	require.Equal(t, "private", storage.repository[id].Header)
+
+	err = storage.DeleteEvent(ownerCtx, id, 0)
+	require.NoError(t, err)
+}
+
+func TestDateBusy(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+	storage := New(logg)
+	ctx := context.Background()
+
+	start := time.Now()
+	finish := start.Add(time.Hour)
+	id, err := storage.CreateEvent(ctx, models.Event{
+		Header:          "meeting",
+		UserID:          "user",
+		EventTime:       start,
+		FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+
+	overlapFinish := finish.Add(time.Hour)
+	_, err = storage.CreateEvent(ctx, models.Event{
+		Header:          "overlapping meeting",
+		UserID:          "user",
+		EventTime:       start.Add(30 * time.Minute),
+		FinishEventTime: &overlapFinish,
+	})
+	require.ErrorIs(t, err, app.ErrDateBusy)
+
+	_, err = storage.CreateEvent(ctx, models.Event{
+		Header:          "meeting of another user",
+		UserID:          "another user",
+		EventTime:       start,
+		FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+
+	nextID, err := storage.CreateEvent(ctx, models.Event{
+		Header:          "next meeting",
+		UserID:          "user",
+		EventTime:       finish,
+		FinishEventTime: &overlapFinish,
+	})
+	require.NoError(t, err)
+
+	err = storage.UpdateEvent(ctx, models.Event{
+		ID:              nextID,
+		Header:          "moved meeting",
+		UserID:          "user",
+		EventTime:       start,
+		FinishEventTime: &overlapFinish,
+	})
+	require.ErrorIs(t, err, app.ErrDateBusy)
+
+	err = storage.UpdateEvent(ctx, models.Event{
+		ID:              id,
+		Header:          "shortened meeting",
+		UserID:          "user",
+		EventTime:       start,
+		FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+}
+
+func TestConformance(t *testing.T) {
+	logg, err := logger.GetLogger("INFO")
+	require.NoError(t, err)
+
+	storagetest.Run(t, func(_ *testing.T) app.Storage {
+		return New(logg)
+	})
+	storagetest.RunNotifications(t, func(_ *testing.T) storagetest.NotificationStorage {
+		return New(logg)
+	})
+}
@@ -3,11 +3,16 @@ package sqlstorage
 //nolint:depguard
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
 	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	// Empty import to ensure execution of code in the package's init function.
 	_ "github.com/lib/pq"
@@ -15,10 +20,51 @@ import (
 )
 
 const (
+	exclusionViolationCode        = "23P01"
+	invalidTextRepresentationCode = "22P02"
+	stringDataRightTruncationCode = "22001"
+	dataExceptionCode             = "22000" // tstzrange of a finish before the start
+	checkViolationCode            = "23514"
+
 	MaxConnections = 10
 	EventTable     = "event"
+	AuditTable     = "event_audit"
+	WatermarkTable = "scheduler_watermark"
+	LedgerTable    = "notification_ledger"
+	OutboxTable    = "notification_outbox"
+	outboxColumns  = "id, payload, attempts, next_attempt_at, last_error, created_at, sent_at"
+	auditColumns   = "id, event_id, user_id, actor, action, before, after, created_at"
+	eventColumns   = "id, header, description, user_id, event_time, finish_event_time, notification_time, " +
+		"recurrence_rule, exception_dates, time_zone, version, deleted_at, remind_before"
+	// lockStatement reads the event which is about to change and locks it until the end of the transaction,
+	// so the audit entry gets the state the change was made to.
+	lockStatement = "SELECT " + eventColumns + " FROM " + EventTable + " WHERE id = $1 FOR UPDATE"
+
+	// AuditChannel is notified with the id of every new audit entry.
+	AuditChannel = "event_audit"
+	// auditLockKey is the key of the advisory lock taken by transactions writing audit entries.
+	auditLockKey = 0x6175646974
 )
 
+// patchColumns maps patchable fields of models.Event to columns and their values.
+var patchColumns = map[string]func(event models.Event) (string, interface{}){
+	"header":      func(event models.Event) (string, interface{}) { return "header", event.Header },
+	"description": func(event models.Event) (string, interface{}) { return "description", event.Description },
+	"eventTime":   func(event models.Event) (string, interface{}) { return "event_time", event.EventTime },
+	"finishEventTime": func(event models.Event) (string, interface{}) {
+		return "finish_event_time", event.FinishEventTime
+	},
+	"notificationTime": func(event models.Event) (string, interface{}) {
+		return "notification_time", event.NotificationTime
+	},
+	"recurrence": func(event models.Event) (string, interface{}) { return "recurrence_rule", event.Recurrence },
+	"exDates":    func(event models.Event) (string, interface{}) { return "exception_dates", event.ExDates },
+	"timeZone":   func(event models.Event) (string, interface{}) { return "time_zone", event.TimeZone },
+	"remindBefore": func(event models.Event) (string, interface{}) {
+		return "remind_before", time.Duration(event.RemindBefore)
+	},
+}
+
 type PostgresStorage struct {
 	databaseURL    string
 	migrationsPath string
@@ -80,114 +126,659 @@ func (s *PostgresStorage) Close() {
 
 func (s *PostgresStorage) CreateEvent(ctx context.Context, eventDTO models.Event) (string, error) {
 	var id string
+	err := s.inAuditedTransaction(ctx, func(tx pgx.Tx) error {
+		sql, args := createStatement(eventDTO)
+		event, err := scanEvent(tx.QueryRow(ctx, sql+" RETURNING "+eventColumns, args...))
+		if err != nil {
+			s.logger.Error("error while creating new event", map[string]interface{}{"error": err})
+			return fmt.Errorf("error while creating new event: %w", translateError(err))
+		}
+		id = event.ID
+
+		return s.addAuditEntries(ctx, tx, app.NewAuditEntry(ctx, models.AuditCreate, nil, &event))
+	})
+
+	return id, err
+}
+
+func (s *PostgresStorage) UpdateEvent(ctx context.Context, eventDTO models.Event) error {
+	if eventDTO.ID == "" {
+		s.logger.Error("event id is required parameter", nil)
+		return fmt.Errorf("%w: event id is required parameter", app.ErrInvalidArgument)
+	}
+
+	return s.inAuditedTransaction(ctx, func(tx pgx.Tx) error {
+		before, err := s.lockEvent(ctx, tx, eventDTO.ID)
+		if err != nil {
+			return err
+		}
+
+		sql, args := updateStatement(ctx, eventDTO)
+		event, err := scanEvent(tx.QueryRow(ctx, sql+" RETURNING "+eventColumns, args...))
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("no objects have been modified", map[string]interface{}{"id": eventDTO.ID})
+			return s.missingEventError(ctx, tx, eventDTO.ID)
+		}
+
+		if err != nil {
+			s.logger.Error("error while updating event", map[string]interface{}{"error": err})
+			return fmt.Errorf("error while updating event: %w", translateError(err))
+		}
+
+		return s.addAuditEntries(ctx, tx, app.NewAuditEntry(ctx, models.AuditUpdate, before, &event))
+	})
+}
+
+// PatchEvent updates only the columns of the patched fields in a single statement, an empty patch changes nothing.
+func (s *PostgresStorage) PatchEvent(ctx context.Context, id string, patch models.EventPatch) (models.Event, error) {
+	if len(patch.Fields) == 0 {
+		event, err := s.GetEvent(ctx, id)
+		if err == nil && patch.Event.Version != 0 && event.Version != patch.Event.Version {
+			return models.Event{}, app.ErrVersionMismatch
+		}
+
+		return event, err
+	}
+
+	sets := make([]string, 0, len(patch.Fields))
+	args := make([]interface{}, 0, len(patch.Fields)+2)
+	for _, field := range patch.Fields {
+		column, ok := patchColumns[field]
+		if !ok {
+			s.logger.Error("field can not be patched", map[string]interface{}{"field": field, "id": id})
+			return models.Event{}, fmt.Errorf("%w: field %q can not be patched", app.ErrInvalidArgument, field)
+		}
+
+		name, value := column(patch.Event)
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", name, len(args)))
+	}
+
+	args = append(args, id)
+	sql := fmt.Sprintf("UPDATE %s SET %s, version = version + 1 WHERE id = $%d AND deleted_at IS NULL",
+		EventTable, strings.Join(sets, ", "), len(args))
+	sql, args = scopeByUser(ctx, sql, args)
+	sql, args = expectVersion(sql, args, patch.Event.Version)
+
+	var event models.Event
+	err := s.inAuditedTransaction(ctx, func(tx pgx.Tx) error {
+		before, err := s.lockEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		event, err = scanEvent(tx.QueryRow(ctx, sql+" RETURNING "+eventColumns, args...))
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("no objects have been patched", map[string]interface{}{"id": id})
+			return s.missingEventError(ctx, tx, id)
+		}
+
+		if err != nil {
+			s.logger.Error("error while patching event", map[string]interface{}{"error": err, "id": id})
+			return fmt.Errorf("error while patching event: %w", translateError(err))
+		}
+
+		return s.addAuditEntries(ctx, tx, app.NewAuditEntry(ctx, models.AuditUpdate, before, &event))
+	})
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	return event, nil
+}
+
+// DeleteEvent moves the event to the trash, it is purged by the scheduler after the retention period.
+func (s *PostgresStorage) DeleteEvent(ctx context.Context, id string, version int64) error {
+	return s.inAuditedTransaction(ctx, func(tx pgx.Tx) error {
+		before, err := s.lockEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		sql, args := deleteStatement(ctx, id, version)
+		result, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			s.logger.Error("error while deleting event", map[string]interface{}{"error": err, "eventID": id})
+			return fmt.Errorf("error while deleting event: %w", translateError(err))
+		}
+
+		if result.RowsAffected() == 0 {
+			s.logger.Error("no objects have been deleted", map[string]interface{}{"id": id})
+			return s.missingEventError(ctx, tx, id)
+		}
+
+		return s.addAuditEntries(ctx, tx, app.NewAuditEntry(ctx, models.AuditDelete, before, nil))
+	})
+}
+
+// ApplyBatch applies the items in a single transaction with their audit entries. An atomic batch is sent
+// to the database in one round trip and rolled back at the first failed item, otherwise every item runs
+// in a savepoint.
+func (s *PostgresStorage) ApplyBatch(
+	ctx context.Context, items []models.BatchItem, atomic bool,
+) ([]models.BatchResult, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.logger.Error("error while starting transaction", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var results []models.BatchResult
+	if atomic {
+		results, err = s.applyAtomicBatch(ctx, tx, items)
+	} else {
+		results, err = s.applyBatchItems(ctx, tx, items)
+	}
+
+	if err != nil {
+		// The failed transaction is rolled back first, so the failed item is explained by the committed state.
+		_ = tx.Rollback(ctx)
+		return s.batchFailure(ctx, items, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.logger.Error("error while committing batch", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while committing batch: %w", translateError(err))
+	}
+
+	return results, nil
+}
+
+// applyAtomicBatch sends every item in one pgx batch, the first failed item is returned as a batchItemError.
+// Events changed by the items are locked by the batch first, the audit entries follow in another round trip.
+func (s *PostgresStorage) applyAtomicBatch(
+	ctx context.Context, tx pgx.Tx, items []models.BatchItem,
+) ([]models.BatchResult, error) {
+	batch := &pgx.Batch{}
+	for i, item := range items {
+		sql, args, err := batchStatement(ctx, item)
+		if err != nil {
+			return nil, &batchItemError{index: i, err: err}
+		}
+
+		if item.Operation != models.BatchCreate {
+			batch.Queue(lockStatement, item.Event.ID)
+		}
+		batch.Queue(sql+" RETURNING "+eventColumns, args...)
+	}
+
+	results := make([]models.BatchResult, len(items))
+	entries := make([]models.AuditEntry, 0, len(items))
+	batchResults := tx.SendBatch(ctx, batch)
+	for i, item := range items {
+		var before *models.Event
+		if item.Operation != models.BatchCreate {
+			event, err := scanEvent(batchResults.QueryRow())
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				_ = batchResults.Close()
+				return nil, &batchItemError{index: i, err: err}
+			}
+
+			if err == nil {
+				before = &event
+			}
+		}
+
+		event, err := scanEvent(batchResults.QueryRow())
+		if err != nil {
+			_ = batchResults.Close()
+			return nil, &batchItemError{index: i, err: err}
+		}
+
+		results[i] = models.BatchResult{ID: event.ID, Event: event}
+		entries = append(entries, batchAuditEntry(ctx, item, before, event))
+	}
+
+	if err := batchResults.Close(); err != nil {
+		return nil, fmt.Errorf("error while closing batch: %w", err)
+	}
+
+	if err := s.addAuditEntries(ctx, tx, entries...); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// applyBatchItems runs every item in its own savepoint, so a failed item does not abort the transaction.
+// Audit entries of the applied items are recorded after the last item.
+func (s *PostgresStorage) applyBatchItems(
+	ctx context.Context, tx pgx.Tx, items []models.BatchItem,
+) ([]models.BatchResult, error) {
+	results := make([]models.BatchResult, len(items))
+	entries := make([]models.AuditEntry, 0, len(items))
+	for i, item := range items {
+		sql, args, err := batchStatement(ctx, item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating savepoint: %w", err)
+		}
+
+		event, before, err := s.applyBatchItem(ctx, savepoint, item, sql, args)
+		if err != nil {
+			if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+				return nil, fmt.Errorf("error while rolling back to savepoint: %w", rollbackErr)
+			}
+
+			results[i].Err = s.batchError(ctx, tx, item, err)
+			continue
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("error while releasing savepoint: %w", err)
+		}
+
+		results[i] = models.BatchResult{ID: event.ID, Event: event}
+		entries = append(entries, batchAuditEntry(ctx, item, before, event))
+	}
+
+	if err := s.addAuditEntries(ctx, tx, entries...); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// applyBatchItem applies the statement of the item, the state of the event before it is returned for the audit.
+func (s *PostgresStorage) applyBatchItem(
+	ctx context.Context, tx pgx.Tx, item models.BatchItem, sql string, args []interface{},
+) (models.Event, *models.Event, error) {
+	var before *models.Event
+	if item.Operation != models.BatchCreate {
+		var err error
+		if before, err = s.lockEvent(ctx, tx, item.Event.ID); err != nil {
+			return models.Event{}, nil, err
+		}
+	}
+
+	event, err := scanEvent(tx.QueryRow(ctx, sql+" RETURNING "+eventColumns, args...))
+	if err != nil {
+		return models.Event{}, nil, err
+	}
+
+	return event, before, nil
+}
+
+// batchAuditEntry describes the change made by the batch item, event is the state the item left.
+func batchAuditEntry(
+	ctx context.Context, item models.BatchItem, before *models.Event, event models.Event,
+) models.AuditEntry {
+	if item.Operation == models.BatchDelete {
+		return app.NewAuditEntry(ctx, models.AuditDelete, before, nil)
+	}
+
+	return app.NewAuditEntry(ctx, item.AuditAction(), before, &event)
+}
+
+// batchFailure reports the failed item of a rolled back atomic batch, other errors are returned as is.
+func (s *PostgresStorage) batchFailure(
+	ctx context.Context, items []models.BatchItem, err error,
+) ([]models.BatchResult, error) {
+	var itemErr *batchItemError
+	if !errors.As(err, &itemErr) {
+		s.logger.Error("error while applying batch", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while applying batch: %w", translateError(err))
+	}
+
+	results := make([]models.BatchResult, len(items))
+	results[itemErr.index].Err = s.batchError(ctx, s.db, items[itemErr.index], itemErr.err)
+	s.logger.Info("batch was rolled back", map[string]interface{}{"failedItem": itemErr.index, "error": itemErr.err})
+
+	return results, nil
+}
+
+// batchError translates the error of a batch item, an item without affected rows is explained by missingEventError.
+func (s *PostgresStorage) batchError(ctx context.Context, q querier, item models.BatchItem, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.missingEventError(ctx, q, item.Event.ID)
+	}
+
+	s.logger.Error("error while applying batch item", map[string]interface{}{"error": err, "id": item.Event.ID})
+	return fmt.Errorf("error while applying batch item: %w", translateError(err))
+}
+
+// querier is satisfied by the pool and by transactions.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// executor is satisfied by the pool and by transactions.
+type executor interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+type batchItemError struct {
+	index int
+	err   error
+}
+
+func (e *batchItemError) Error() string {
+	return fmt.Sprintf("batch item %d: %s", e.index, e.err)
+}
+
+func (e *batchItemError) Unwrap() error {
+	return e.err
+}
+
+func batchStatement(ctx context.Context, item models.BatchItem) (string, []interface{}, error) {
+	switch item.Operation {
+	case models.BatchCreate:
+		sql, args := createStatement(item.Event)
+		return sql, args, nil
+	case models.BatchUpdate:
+		sql, args := updateStatement(ctx, item.Event)
+		return sql, args, nil
+	case models.BatchDelete:
+		sql, args := deleteStatement(ctx, item.Event.ID, item.Event.Version)
+		return sql, args, nil
+	default:
+		return "", nil, fmt.Errorf("%w: unknown batch operation %q", app.ErrInvalidArgument, item.Operation)
+	}
+}
+
+func createStatement(eventDTO models.Event) (string, []interface{}) {
 	sql := fmt.Sprintf(
-		"INSERT INTO %s (header,description,user_id,event_time,finish_event_time,notification_time) "+
-			"VALUES($1, $2, $3, $4, $5, $6) RETURNING id", EventTable)
-	err := s.db.QueryRow(
-		ctx,
-		sql,
+		"INSERT INTO %s (header,description,user_id,event_time,finish_event_time,notification_time,"+
+			"recurrence_rule,exception_dates,time_zone,remind_before) "+
+			"VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)", EventTable)
+
+	return sql, []interface{}{
 		eventDTO.Header, eventDTO.Description, eventDTO.UserID,
 		eventDTO.EventTime, eventDTO.FinishEventTime, eventDTO.NotificationTime,
-	).Scan(&id)
-	if err != nil {
-		s.logger.Error("error while creating new event", map[string]interface{}{"error": err})
-		return "", fmt.Errorf("error while creating new event: %w", err)
+		eventDTO.Recurrence, eventDTO.ExDates, eventDTO.TimeZone, time.Duration(eventDTO.RemindBefore),
 	}
-
-	return id, nil
 }
 
-func (s *PostgresStorage) UpdateEvent(ctx context.Context, eventDTO models.Event) error {
+func updateStatement(ctx context.Context, eventDTO models.Event) (string, []interface{}) {
 	sql := fmt.Sprintf(
 		"UPDATE %s SET "+
 			"header = $1,description = $2, user_id = $3, event_time = $4,"+
-			" finish_event_time = $5, notification_time = $6 "+
-			"WHERE id = $7", EventTable)
-	result, err := s.db.Exec(
-		ctx,
-		sql,
+			" finish_event_time = $5, notification_time = $6, recurrence_rule = $7, exception_dates = $8,"+
+			" time_zone = $9, remind_before = $10, version = version + 1 WHERE id = $11 AND deleted_at IS NULL",
+		EventTable)
+	sql, args := scopeByUser(ctx, sql, []interface{}{
 		eventDTO.Header, eventDTO.Description, eventDTO.UserID, eventDTO.EventTime, eventDTO.FinishEventTime,
-		eventDTO.NotificationTime, eventDTO.ID,
-	)
+		eventDTO.NotificationTime, eventDTO.Recurrence, eventDTO.ExDates, eventDTO.TimeZone,
+		time.Duration(eventDTO.RemindBefore), eventDTO.ID,
+	})
+
+	return expectVersion(sql, args, eventDTO.Version)
+}
+
+func deleteStatement(ctx context.Context, id string, version int64) (string, []interface{}) {
+	sql := fmt.Sprintf(
+		"UPDATE %s SET deleted_at = now(), version = version + 1 WHERE id = $1 AND deleted_at IS NULL", EventTable)
+	sql, args := scopeByUser(ctx, sql, []interface{}{id})
+
+	return expectVersion(sql, args, version)
+}
+
+func (s *PostgresStorage) GetEvent(ctx context.Context, id string) (models.Event, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL", eventColumns, EventTable)
+	event, err := scanEvent(s.db.QueryRow(ctx, sql, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.logger.Error("event with such an id is does not exist", map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrEventNotFound
+	}
+
 	if err != nil {
-		s.logger.Error("error while updating event", map[string]interface{}{"error": err})
-		return fmt.Errorf("error while updating event: %w", err)
+		s.logger.Error("error while getting event", map[string]interface{}{"error": err, "id": id})
+		return models.Event{}, fmt.Errorf("error while getting event: %w", translateError(err))
 	}
 
-	if result.RowsAffected() == 0 {
-		s.logger.Error("no objects have been modified", nil)
-		return fmt.Errorf("no objects have been modified")
+	if userID, ok := app.UserIDFromContext(ctx); ok && event.UserID != userID {
+		s.logger.Error("event belongs to another user", map[string]interface{}{"id": id})
+		return models.Event{}, app.ErrEventForbidden
 	}
 
-	return nil
+	return event, nil
 }
 
-func (s *PostgresStorage) DeleteEvent(ctx context.Context, id string) error {
+// GetListEventsDuringDay returns events of the day in the location of targetDay.
+func (s *PostgresStorage) GetListEventsDuringDay(ctx context.Context, targetDay time.Time) ([]models.Event, error) {
+	from, to := app.DaysBounds(targetDay, 1)
+
+	return s.GetListEventsInRange(ctx, from, to)
+}
+
+func (s *PostgresStorage) GetListEventsDuringFewDays(
+	ctx context.Context, start time.Time, amountDays int,
+) ([]models.Event, error) {
+	from, to := app.DaysBounds(start, amountDays)
+
+	return s.GetListEventsInRange(ctx, from, to)
+}
+
+func (s *PostgresStorage) GetListEventsInRange(ctx context.Context, from, to time.Time) ([]models.Event, error) {
 	sql := fmt.Sprintf(
-		"DELETE FROM %s WHERE id = $1", EventTable)
-	result, err := s.db.Exec(ctx, sql, id)
+		"SELECT %s FROM %s "+
+			"WHERE ((recurrence_rule = '' AND event_time >= $1 AND event_time < $2) "+
+			"OR (recurrence_rule <> '' AND event_time < $2)) AND deleted_at IS NULL", eventColumns, EventTable)
+	sql, args := scopeByUser(ctx, sql, []interface{}{from, to})
+	rows, err := s.db.Query(ctx, sql, args...)
 	if err != nil {
-		s.logger.Error("error while deleting event", map[string]interface{}{"error": err, "eventID": id})
-		return fmt.Errorf("error while deleting event: %w", err)
+		s.logger.Error("error while getting list events in range",
+			map[string]interface{}{"error": err, "from": from, "to": to})
+		return nil, fmt.Errorf("error while getting list events in range: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		s.logger.Error("no objects have been deleted", nil)
-		return fmt.Errorf("no objects have been deleted")
+	events, err := s.scanEvents(rows)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s.expandOccurrences(events, from, to)
 }
 
-func (s *PostgresStorage) GetListEventsDuringDay(ctx context.Context, targetDay time.Time) ([]models.Event, error) {
-	date := time.Date(targetDay.Year(), targetDay.Month(), targetDay.Day(), 0, 0, 0, 0, targetDay.Location())
+// GetListEventsPage pages single events in the database by keyset,
+// recurring series are expanded and merged into the page afterwards.
+func (s *PostgresStorage) GetListEventsPage(
+	ctx context.Context, from, to time.Time, after *models.Cursor, limit int,
+) ([]models.Event, error) {
 	sql := fmt.Sprintf(
-		"SELECT id, header, description, user_id, event_time, finish_event_time, notification_time "+
-			"FROM %s WHERE DATE(event_time) = $1", EventTable)
-	rows, err := s.db.Query(ctx, sql, date)
+		"SELECT %s FROM %s WHERE recurrence_rule = '' AND event_time >= $1 AND event_time < $2 AND deleted_at IS NULL",
+		eventColumns, EventTable)
+	args := []interface{}{from, to}
+	if after != nil {
+		args = append(args, after.EventTime, after.ID)
+		sql += " AND (event_time, id) > ($3, $4)"
+	}
+	sql, args = scopeByUser(ctx, sql, args)
+	args = append(args, limit)
+	sql = fmt.Sprintf("%s ORDER BY event_time, id LIMIT $%d", sql, len(args))
+
+	rows, err := s.db.Query(ctx, sql, args...)
 	if err != nil {
-		s.logger.Error(
-			"error while getting list events per day", map[string]interface{}{"error": err, "targetDay": targetDay})
-		return nil, fmt.Errorf("error while getting list events per day: %w", err)
+		s.logger.Error("error while getting page of events",
+			map[string]interface{}{"error": err, "from": from, "to": to})
+		return nil, fmt.Errorf("error while getting page of events: %w", translateError(err))
 	}
-	defer rows.Close()
 
-	events := make([]models.Event, 0)
-	for rows.Next() {
-		var event models.Event
-		if err = rows.Scan(&event.ID, &event.Header, &event.Description, &event.UserID, &event.EventTime,
-			&event.FinishEventTime, &event.NotificationTime); err != nil {
-			s.logger.Error("error while scanning event", map[string]interface{}{"error": err})
-			return nil, fmt.Errorf("error while scanning event: %w", err)
+	events, err := s.scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	sql = fmt.Sprintf("SELECT %s FROM %s WHERE recurrence_rule <> '' AND event_time < $1 AND deleted_at IS NULL",
+		eventColumns, EventTable)
+	sql, args = scopeByUser(ctx, sql, []interface{}{to})
+	rows, err = s.db.Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error("error while getting recurring events",
+			map[string]interface{}{"error": err, "from": from, "to": to})
+		return nil, fmt.Errorf("error while getting recurring events: %w", err)
+	}
+
+	series, err := s.scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.expandOccurrences(series, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, occurrence := range occurrences {
+		if after == nil || after.Precedes(occurrence) {
+			events = append(events, occurrence)
 		}
+	}
+	models.SortEvents(events)
 
-		events = append(events, event)
+	if len(events) > limit {
+		events = events[:limit]
 	}
 
 	return events, nil
 }
 
-func (s *PostgresStorage) GetListEventsDuringFewDays(
-	ctx context.Context, start time.Time, amountDays int,
-) ([]models.Event, error) {
-	startDate := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
-	finish := start.AddDate(0, 0, amountDays)
-	finishDate := time.Date(finish.Year(), finish.Month(), finish.Day(), 0, 0, 0, 0, finish.Location())
+// SearchEvents matches the query against the search_vector column, see the add_event_search_vector migration.
+func (s *PostgresStorage) SearchEvents(ctx context.Context, query models.SearchQuery) ([]models.Event, error) {
 	sql := fmt.Sprintf(
-		"SELECT id, header, description, user_id, event_time, finish_event_time, notification_time FROM %s "+
-			"WHERE DATE(event_time) >= $1 AND DATE(event_time) < $2", EventTable)
-	rows, err := s.db.Query(ctx, sql, startDate, finishDate)
+		"SELECT %s FROM %s WHERE search_vector @@ plainto_tsquery('simple', $1) AND deleted_at IS NULL",
+		eventColumns, EventTable)
+	args := []interface{}{query.Text}
+	if query.UserID != "" {
+		args = append(args, query.UserID)
+		sql += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+
+	if !query.From.IsZero() {
+		args = append(args, query.From)
+		sql += fmt.Sprintf(" AND event_time >= $%d", len(args))
+	}
+
+	if !query.To.IsZero() {
+		args = append(args, query.To)
+		sql += fmt.Sprintf(" AND event_time < $%d", len(args))
+	}
+
+	sql, args = scopeByUser(ctx, sql, args)
+	sql += " ORDER BY event_time, id"
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		sql += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Error("error while searching events", map[string]interface{}{"error": err, "query": query.Text})
+		return nil, fmt.Errorf("error while searching events: %w", translateError(err))
+	}
+
+	return s.scanEvents(rows)
+}
+
+// translateError converts violations reported by Postgres into domain errors.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case exclusionViolationCode:
+		return app.ErrDateBusy
+	case invalidTextRepresentationCode, stringDataRightTruncationCode, dataExceptionCode, checkViolationCode:
+		return fmt.Errorf("%w: %s", app.ErrInvalidArgument, pgErr.Message)
+	default:
+		return err
+	}
+}
+
+// missingEventError tells apart events that do not exist, events of another user
+// and events with another version.
+func (s *PostgresStorage) missingEventError(ctx context.Context, q querier, id string) error {
+	var userID string
+	sql := fmt.Sprintf("SELECT user_id FROM %s WHERE id = $1 AND deleted_at IS NULL", EventTable)
+	err := q.QueryRow(ctx, sql, id).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return app.ErrEventNotFound
+	}
+
 	if err != nil {
-		s.logger.Error("error while getting list events per week", map[string]interface{}{"error": err, "startDay": start})
-		return nil, fmt.Errorf("error while getting list events per week: %w", err)
+		s.logger.Error("error while checking event", map[string]interface{}{"error": err, "id": id})
+		return fmt.Errorf("error while checking event: %w", translateError(err))
 	}
+
+	if callerID, ok := app.UserIDFromContext(ctx); ok && callerID != userID {
+		return app.ErrEventForbidden
+	}
+
+	return app.ErrVersionMismatch
+}
+
+// missingDeletedEventError tells apart events that do not exist, events of another user and events not in the trash.
+func (s *PostgresStorage) missingDeletedEventError(ctx context.Context, q querier, id string) error {
+	var userID string
+	var deletedAt *time.Time
+	sql := fmt.Sprintf("SELECT user_id, deleted_at FROM %s WHERE id = $1", EventTable)
+	err := q.QueryRow(ctx, sql, id).Scan(&userID, &deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return app.ErrEventNotFound
+	}
+
+	if err != nil {
+		s.logger.Error("error while checking event", map[string]interface{}{"error": err, "id": id})
+		return fmt.Errorf("error while checking event: %w", translateError(err))
+	}
+
+	if callerID, ok := app.UserIDFromContext(ctx); ok && callerID != userID {
+		return app.ErrEventForbidden
+	}
+
+	return app.ErrEventNotDeleted
+}
+
+// expectVersion restricts the statement to the expected version of the event, if it is not zero.
+func expectVersion(sql string, args []interface{}, version int64) (string, []interface{}) {
+	if version == 0 {
+		return sql, args
+	}
+
+	args = append(args, version)
+
+	return fmt.Sprintf("%s AND version = $%d", sql, len(args)), args
+}
+
+// scopeByUser restricts the query to the events of the user from the context, if there is one.
+func scopeByUser(ctx context.Context, sql string, args []interface{}) (string, []interface{}) {
+	userID, ok := app.UserIDFromContext(ctx)
+	if !ok {
+		return sql, args
+	}
+
+	args = append(args, userID)
+
+	return fmt.Sprintf("%s AND user_id = $%d", sql, len(args)), args
+}
+
+func scanEvent(row pgx.Row) (models.Event, error) {
+	var event models.Event
+	err := row.Scan(&event.ID, &event.Header, &event.Description, &event.UserID, &event.EventTime,
+		&event.FinishEventTime, &event.NotificationTime, &event.Recurrence, &event.ExDates, &event.TimeZone,
+		&event.Version, &event.DeletedAt, (*time.Duration)(&event.RemindBefore))
+
+	return event, err
+}
+
+func (s *PostgresStorage) scanEvents(rows pgx.Rows) ([]models.Event, error) {
 	defer rows.Close()
 
 	events := make([]models.Event, 0)
 	for rows.Next() {
-		var event models.Event
-		if err = rows.Scan(&event.ID, &event.Header, &event.Description, &event.UserID, &event.EventTime,
-			&event.FinishEventTime, &event.NotificationTime); err != nil {
+		event, err := scanEvent(rows)
+		if err != nil {
 			s.logger.Error("error while scanning event", map[string]interface{}{"error": err})
 			return nil, fmt.Errorf("error while scanning event: %w", err)
 		}
@@ -195,46 +786,578 @@ func (s *PostgresStorage) GetListEventsDuringFewDays(
 		events = append(events, event)
 	}
 
+	if err := rows.Err(); err != nil {
+		s.logger.Error("error while reading events", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while reading events: %w", err)
+	}
+
 	return events, nil
 }
 
-func (s *PostgresStorage) DeleteOldEvent(ctx context.Context) error {
+func (s *PostgresStorage) expandOccurrences(events []models.Event, from, to time.Time) ([]models.Event, error) {
+	expanded, err := models.ExpandOccurrences(events, from, to)
+	if err != nil {
+		s.logger.Error("error while expanding recurring events", map[string]interface{}{"error": err})
+		return nil, err
+	}
+	models.SortEvents(expanded)
+
+	return expanded, nil
+}
+
+// GetListDeletedEvents returns the trash, the most recently deleted events go first.
+func (s *PostgresStorage) GetListDeletedEvents(ctx context.Context) ([]models.Event, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE deleted_at IS NOT NULL", eventColumns, EventTable)
+	sql, args := scopeByUser(ctx, sql, nil)
+	rows, err := s.db.Query(ctx, sql+" ORDER BY deleted_at DESC, id", args...)
+	if err != nil {
+		s.logger.Error("error while getting deleted events", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while getting deleted events: %w", err)
+	}
+
+	return s.scanEvents(rows)
+}
+
+func (s *PostgresStorage) RestoreEvent(ctx context.Context, id string) (models.Event, error) {
+	sql := fmt.Sprintf(
+		"UPDATE %s SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL", EventTable)
+	sql, args := scopeByUser(ctx, sql, []interface{}{id})
+
+	var event models.Event
+	err := s.inAuditedTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		event, err = scanEvent(tx.QueryRow(ctx, sql+" RETURNING "+eventColumns, args...))
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Error("no objects have been restored", map[string]interface{}{"id": id})
+			return s.missingDeletedEventError(ctx, tx, id)
+		}
+
+		if err != nil {
+			s.logger.Error("error while restoring event", map[string]interface{}{"error": err, "id": id})
+			return fmt.Errorf("error while restoring event: %w", translateError(err))
+		}
+
+		return s.addAuditEntries(ctx, tx, app.NewAuditEntry(ctx, models.AuditRestore, nil, &event))
+	})
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	return event, nil
+}
+
+// PurgeDeletedEvents permanently removes events which were moved to the trash before deletedBefore.
+func (s *PostgresStorage) PurgeDeletedEvents(ctx context.Context, deletedBefore time.Time) error {
 	sql := fmt.Sprintf(
-		"DELETE FROM %s WHERE event_time < $1", EventTable)
-	result, err := s.db.Exec(ctx, sql, time.Now().AddDate(-1, 0, 0))
+		"DELETE FROM %s WHERE deleted_at < $1", EventTable)
+	result, err := s.db.Exec(ctx, sql, deletedBefore)
 	if err != nil {
-		s.logger.Error("error while deleting old events", map[string]interface{}{"error": err})
-		return fmt.Errorf("error while deleting event: %w", err)
+		s.logger.Error("error while purging deleted events", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while purging deleted events: %w", err)
 	}
 
 	if count := result.RowsAffected(); count != 0 {
-		s.logger.Error(fmt.Sprintf("%d events have been deleted", count), nil)
+		s.logger.Info(fmt.Sprintf("%d events have been purged", count), nil)
 	}
 
 	return nil
 }
 
-func (s *PostgresStorage) GetNotifications(ctx context.Context) ([]models.Notification, error) {
-	sql := fmt.Sprintf(
-		"SELECT id, header, user_id, event_time FROM %s WHERE DATE(notification_time) = DATE($1)", EventTable)
-	rows, err := s.db.Query(ctx, sql, time.Now())
+// inAuditedTransaction runs f in a transaction, which is committed if f succeeds.
+// f records its audit entries by addAuditEntries as the last statement.
+func (s *PostgresStorage) inAuditedTransaction(ctx context.Context, f func(tx pgx.Tx) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.logger.Error("error while starting transaction", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = f(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		s.logger.Error("error while committing transaction", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while committing transaction: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// lockEvent reads the event by lockStatement, nil is returned if there is no such event.
+func (s *PostgresStorage) lockEvent(ctx context.Context, q querier, id string) (*models.Event, error) {
+	event, err := scanEvent(q.QueryRow(ctx, lockStatement, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		s.logger.Error("error while locking event", map[string]interface{}{"error": err, "id": id})
+		return nil, fmt.Errorf("error while locking event: %w", translateError(err))
+	}
+
+	return &event, nil
+}
+
+// lockAuditLog takes the audit lock until the end of the transaction. Ids of audit entries are revisions and
+// GetChanges reads the entries after the last seen one, so an id must not become visible before every lower one.
+// The sequence alone does not give that: ids are handed out when rows are inserted, not when transactions commit,
+// and a reader could pass an id whose transaction commits later. Neither do transaction ids of the rows,
+// a transaction started earlier may write its entries later.
+// Only the audit insert and the commit run under the lock, see addAuditEntries, so writers are serialised
+// for that tail alone. A holder of the lock waits for no row lock, the audit table has no foreign keys,
+// so transactions do not wait for each other in a cycle.
+func (s *PostgresStorage) lockAuditLog(ctx context.Context, db executor) error {
+	if _, err := db.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+		s.logger.Error("error while locking audit log", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while locking audit log: %w", err)
+	}
+
+	return nil
+}
+
+// addAuditEntries records audit entries of the changes made in the transaction in one statement under the audit
+// lock, it must be the last statement of the transaction.
+func (s *PostgresStorage) addAuditEntries(ctx context.Context, db executor, entries ...models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := s.lockAuditLog(ctx, db); err != nil {
+		return err
+	}
+
+	values := make([]string, 0, len(entries))
+	args := make([]interface{}, 0, 7*len(entries))
+	for _, entry := range entries {
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			len(args)+1, len(args)+2, len(args)+3, len(args)+4, len(args)+5, len(args)+6, len(args)+7))
+		args = append(args, entry.EventID, entry.UserID, entry.Actor, entry.Action, entry.Before, entry.After,
+			entry.Time)
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (event_id, user_id, actor, action, before, after, created_at) VALUES %s",
+		AuditTable, strings.Join(values, ", "))
+	if _, err := db.Exec(ctx, sql, args...); err != nil {
+		s.logger.Error("error while adding audit entries", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while adding audit entries: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (s *PostgresStorage) GetEventHistory(ctx context.Context, eventID string) ([]models.AuditEntry, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE event_id = $1", auditColumns, AuditTable)
+	sql, args := scopeByUser(ctx, sql, []interface{}{eventID})
+	rows, err := s.db.Query(ctx, sql+" ORDER BY created_at, id", args...)
+	if err != nil {
+		s.logger.Error("error while getting event history", map[string]interface{}{"error": err, "id": eventID})
+		return nil, fmt.Errorf("error while getting event history: %w", translateError(err))
+	}
+
+	return s.scanAuditEntries(rows)
+}
+
+// GetChanges reads audit entries by id, which grows in the order the entries are committed, see addAuditEntries.
+func (s *PostgresStorage) GetChanges(
+	ctx context.Context, afterRevision int64, limit int,
+) ([]models.AuditEntry, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE id > $1", auditColumns, AuditTable)
+	sql, args := scopeByUser(ctx, sql, []interface{}{afterRevision})
+	args = append(args, limit)
+	rows, err := s.db.Query(ctx, fmt.Sprintf("%s ORDER BY id LIMIT $%d", sql, len(args)), args...)
+	if err != nil {
+		s.logger.Error("error while getting changes", map[string]interface{}{"error": err, "revision": afterRevision})
+		return nil, fmt.Errorf("error while getting changes: %w", err)
+	}
+
+	return s.scanAuditEntries(rows)
+}
+
+func (s *PostgresStorage) GetRevision(ctx context.Context) (int64, error) {
+	var revision int64
+	sql := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", AuditTable)
+	if err := s.db.QueryRow(ctx, sql).Scan(&revision); err != nil {
+		s.logger.Error("error while getting revision", map[string]interface{}{"error": err})
+		return 0, fmt.Errorf("error while getting revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+// WatchRevisions listens to notifications sent by the trigger of the audit table on a dedicated connection,
+// so changes made by every instance of the service are reported.
+func (s *PostgresStorage) WatchRevisions(ctx context.Context) (<-chan int64, error) {
+	conn, err := s.db.Acquire(ctx)
 	if err != nil {
-		s.logger.Error("error while getting list events for notification", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("error while getting list events for notification: %w", err)
+		s.logger.Error("error while acquiring connection", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while acquiring connection: %w", err)
+	}
+
+	if _, err := conn.Exec(ctx, "LISTEN "+AuditChannel); err != nil {
+		conn.Release()
+		s.logger.Error("error while listening to changes", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while listening to changes: %w", err)
 	}
+
+	revisions := make(chan int64, 1)
+	go func() {
+		defer close(revisions)
+		// The connection is still listening, so it is closed instead of being returned to the pool.
+		defer func() {
+			_ = conn.Hijack().Close(context.Background())
+		}()
+
+		for {
+			notification, err := conn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Error("error while waiting for changes", map[string]interface{}{"error": err})
+				}
+				return
+			}
+
+			revision, err := strconv.ParseInt(notification.Payload, 10, 64)
+			if err != nil {
+				s.logger.Error("invalid revision", map[string]interface{}{"error": err, "payload": notification.Payload})
+				continue
+			}
+
+			// The receiver reads every change after its last revision, so a pending revision is enough.
+			select {
+			case revisions <- revision:
+			default:
+			}
+		}
+	}()
+
+	return revisions, nil
+}
+
+func (s *PostgresStorage) scanAuditEntries(rows pgx.Rows) ([]models.AuditEntry, error) {
 	defer rows.Close()
 
-	notifications := make([]models.Notification, 0)
+	entries := make([]models.AuditEntry, 0)
 	for rows.Next() {
-		var notification models.Notification
-		if err = rows.Scan(
-			&notification.ID, &notification.EventHeader, &notification.UserID, &notification.EventTime); err != nil {
-			s.logger.Error("error while scanning notification", map[string]interface{}{"error": err})
-			return nil, fmt.Errorf("error while scanning notification: %w", err)
+		var entry models.AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.EventID, &entry.UserID, &entry.Actor, &entry.Action,
+			&entry.Before, &entry.After, &entry.Time); err != nil {
+			s.logger.Error("error while scanning audit entry", map[string]interface{}{"error": err})
+			return nil, fmt.Errorf("error while scanning audit entry: %w", err)
 		}
 
-		notifications = append(notifications, notification)
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading audit entries: %w", err)
 	}
 
+	return entries, nil
+}
+
+// GetNotifications returns notifications due within [from, to) ordered by their notification time.
+func (s *PostgresStorage) GetNotifications(ctx context.Context, from, to time.Time) ([]models.Notification, error) {
+	return s.getNotifications(ctx, s.db, from, to)
+}
+
+func (s *PostgresStorage) getNotifications(
+	ctx context.Context, db executor, from, to time.Time,
+) ([]models.Notification, error) {
+	// Occurrences of recurring events may notify long after notification_time of the first one.
+	sql := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE notification_time IS NOT NULL AND deleted_at IS NULL "+
+			"AND ((notification_time >= $1 AND notification_time < $2) "+
+			"OR (recurrence_rule <> '' AND notification_time < $2))", eventColumns, EventTable)
+	rows, err := db.Query(ctx, sql, from, to)
+	if err != nil {
+		s.logger.Error("error while getting events for notification", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while getting events for notification: %w", err)
+	}
+
+	events, err := s.scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]models.Notification, 0)
+	for _, event := range events {
+		eventNotifications, err := event.NotificationsIn(from, to)
+		if err != nil {
+			s.logger.Error("error while expanding recurring event", map[string]interface{}{"error": err, "id": event.ID})
+			return nil, err
+		}
+
+		notifications = append(notifications, eventNotifications...)
+	}
+	models.SortNotifications(notifications)
+
 	return notifications, nil
 }
+
+// GetWatermark returns the watermark saved by the scheduler with the name, zero time if there is none.
+func (s *PostgresStorage) GetWatermark(ctx context.Context, name string) (time.Time, error) {
+	var watermark time.Time
+	sql := fmt.Sprintf("SELECT watermark FROM %s WHERE name = $1", WatermarkTable)
+	err := s.db.QueryRow(ctx, sql, name).Scan(&watermark)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		s.logger.Error("error while getting watermark", map[string]interface{}{"error": err, "name": name})
+		return time.Time{}, fmt.Errorf("error while getting watermark: %w", err)
+	}
+
+	return watermark, nil
+}
+
+func (s *PostgresStorage) SetWatermark(ctx context.Context, name string, watermark time.Time) error {
+	return s.setWatermark(ctx, s.db, name, watermark)
+}
+
+func (s *PostgresStorage) setWatermark(ctx context.Context, db executor, name string, watermark time.Time) error {
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (name, watermark) VALUES ($1, $2) "+
+			"ON CONFLICT (name) DO UPDATE SET watermark = EXCLUDED.watermark", WatermarkTable)
+	if _, err := db.Exec(ctx, sql, name, watermark); err != nil {
+		s.logger.Error("error while setting watermark", map[string]interface{}{"error": err, "name": name})
+		return fmt.Errorf("error while setting watermark: %w", err)
+	}
+
+	return nil
+}
+
+// GetNotificationState returns the state of the notification in the ledger, empty if it was never published.
+func (s *PostgresStorage) GetNotificationState(
+	ctx context.Context, notification models.Notification,
+) (models.NotificationState, error) {
+	var state models.NotificationState
+	sql := fmt.Sprintf("SELECT state FROM %s WHERE event_id = $1 AND occurrence = $2", LedgerTable)
+	err := s.db.QueryRow(ctx, sql, notification.ID, notification.EventTime).Scan(&state)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		s.logger.Error("error while getting notification state",
+			map[string]interface{}{"error": err, "key": notification.Key()})
+		return "", fmt.Errorf("error while getting notification state: %w", err)
+	}
+
+	return state, nil
+}
+
+// MarkNotificationPublished records the notification as published unless it is in the ledger already.
+func (s *PostgresStorage) MarkNotificationPublished(ctx context.Context, notification models.Notification) error {
+	return s.markNotificationPublished(ctx, s.db, notification)
+}
+
+func (s *PostgresStorage) markNotificationPublished(
+	ctx context.Context, db executor, notification models.Notification,
+) error {
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (event_id, occurrence, state, published_at) VALUES ($1, $2, $3, now()) "+
+			"ON CONFLICT (event_id, occurrence) DO NOTHING", LedgerTable)
+	_, err := db.Exec(ctx, sql, notification.ID, notification.EventTime, models.NotificationPublished)
+	if err != nil {
+		s.logger.Error("error while marking notification published",
+			map[string]interface{}{"error": err, "key": notification.Key()})
+		return fmt.Errorf("error while marking notification published: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimNotification records the notification as being delivered, it returns false if the notification
+// has been delivered or claimed by another sender. The claim is taken by one statement, so of senders
+// claiming the notification at the same time only one succeeds.
+func (s *PostgresStorage) ClaimNotification(ctx context.Context, notification models.Notification) (bool, error) {
+	sql := fmt.Sprintf(
+		"INSERT INTO %[1]s (event_id, occurrence, state) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (event_id, occurrence) DO UPDATE SET state = EXCLUDED.state "+
+			"WHERE %[1]s.state = $4", LedgerTable)
+	tag, err := s.db.Exec(ctx, sql,
+		notification.ID, notification.EventTime, models.NotificationDelivering, models.NotificationPublished)
+	if err != nil {
+		s.logger.Error("error while claiming notification",
+			map[string]interface{}{"error": err, "key": notification.Key()})
+		return false, fmt.Errorf("error while claiming notification: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseNotification takes back the claim of the notification which could not be delivered.
+func (s *PostgresStorage) ReleaseNotification(ctx context.Context, notification models.Notification) error {
+	sql := fmt.Sprintf(
+		"UPDATE %s SET state = $1 WHERE event_id = $2 AND occurrence = $3 AND state = $4", LedgerTable)
+	_, err := s.db.Exec(ctx, sql,
+		models.NotificationPublished, notification.ID, notification.EventTime, models.NotificationDelivering)
+	if err != nil {
+		s.logger.Error("error while releasing notification",
+			map[string]interface{}{"error": err, "key": notification.Key()})
+		return fmt.Errorf("error while releasing notification: %w", err)
+	}
+
+	return nil
+}
+
+// MarkNotificationDelivered records the notification as delivered,
+// it returns false if the notification has been delivered before.
+func (s *PostgresStorage) MarkNotificationDelivered(
+	ctx context.Context, notification models.Notification,
+) (bool, error) {
+	sql := fmt.Sprintf(
+		"INSERT INTO %[1]s (event_id, occurrence, state, delivered_at) VALUES ($1, $2, $3, now()) "+
+			"ON CONFLICT (event_id, occurrence) DO UPDATE SET state = EXCLUDED.state, delivered_at = now() "+
+			"WHERE %[1]s.state <> EXCLUDED.state", LedgerTable)
+	tag, err := s.db.Exec(ctx, sql, notification.ID, notification.EventTime, models.NotificationDelivered)
+	if err != nil {
+		s.logger.Error("error while marking notification delivered",
+			map[string]interface{}{"error": err, "key": notification.Key()})
+		return false, fmt.Errorf("error while marking notification delivered: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// EnqueueNotifications puts notifications due within [from, to) into the outbox and moves the watermark
+// with the name to the end of the window in one transaction. Notifications in the outbox or in the ledger
+// are not enqueued again, the number of enqueued ones is returned.
+func (s *PostgresStorage) EnqueueNotifications(
+	ctx context.Context, watermarkName string, from, to time.Time,
+) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.logger.Error("error while starting transaction", map[string]interface{}{"error": err})
+		return 0, fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	notifications, err := s.getNotifications(ctx, tx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (event_id, occurrence, payload) SELECT $1::uuid, $2::timestamptz, $3::jsonb "+
+			"WHERE NOT EXISTS (SELECT 1 FROM %s WHERE event_id = $1 AND occurrence = $2) "+
+			"ON CONFLICT (event_id, occurrence) DO NOTHING", OutboxTable, LedgerTable)
+	enqueued := 0
+	for _, notification := range notifications {
+		tag, err := tx.Exec(ctx, sql, notification.ID, notification.EventTime, notification)
+		if err != nil {
+			s.logger.Error("error while enqueueing notification",
+				map[string]interface{}{"error": err, "key": notification.Key()})
+			return 0, fmt.Errorf("error while enqueueing notification: %w", err)
+		}
+
+		enqueued += int(tag.RowsAffected())
+	}
+
+	if err = s.setWatermark(ctx, tx, watermarkName, to); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		s.logger.Error("error while committing notifications", map[string]interface{}{"error": err})
+		return 0, fmt.Errorf("error while committing notifications: %w", err)
+	}
+
+	return enqueued, nil
+}
+
+// GetOutbox returns at most limit unsent messages of the outbox due to be published at now, the oldest first.
+func (s *PostgresStorage) GetOutbox(ctx context.Context, now time.Time, limit int) ([]models.OutboxMessage, error) {
+	sql := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE sent_at IS NULL AND next_attempt_at <= $1 ORDER BY id LIMIT $2",
+		outboxColumns, OutboxTable)
+	rows, err := s.db.Query(ctx, sql, now, limit)
+	if err != nil {
+		s.logger.Error("error while getting outbox", map[string]interface{}{"error": err})
+		return nil, fmt.Errorf("error while getting outbox: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]models.OutboxMessage, 0)
+	for rows.Next() {
+		var message models.OutboxMessage
+		if err = rows.Scan(&message.ID, &message.Notification, &message.Attempts, &message.NextAttempt,
+			&message.LastError, &message.CreatedAt, &message.SentAt); err != nil {
+			s.logger.Error("error while scanning outbox message", map[string]interface{}{"error": err})
+			return nil, fmt.Errorf("error while scanning outbox message: %w", err)
+		}
+
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading outbox: %w", err)
+	}
+
+	return messages, nil
+}
+
+// MarkOutboxSent marks the message sent and records its notification in the ledger as published.
+func (s *PostgresStorage) MarkOutboxSent(ctx context.Context, message models.OutboxMessage) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.logger.Error("error while starting transaction", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while starting transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	sql := fmt.Sprintf("UPDATE %s SET sent_at = now() WHERE id = $1", OutboxTable)
+	if _, err = tx.Exec(ctx, sql, message.ID); err != nil {
+		s.logger.Error("error while marking outbox message sent", map[string]interface{}{"error": err, "id": message.ID})
+		return fmt.Errorf("error while marking outbox message sent: %w", err)
+	}
+
+	if err = s.markNotificationPublished(ctx, tx, message.Notification); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		s.logger.Error("error while committing outbox message", map[string]interface{}{"error": err})
+		return fmt.Errorf("error while committing outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// MarkOutboxFailed counts the failed attempt to publish the message and puts off the next one.
+func (s *PostgresStorage) MarkOutboxFailed(ctx context.Context, id int64, nextAttempt time.Time, reason string) error {
+	sql := fmt.Sprintf(
+		"UPDATE %s SET attempts = attempts + 1, next_attempt_at = $1, last_error = $2 WHERE id = $3", OutboxTable)
+	if _, err := s.db.Exec(ctx, sql, nextAttempt, reason, id); err != nil {
+		s.logger.Error("error while marking outbox message failed", map[string]interface{}{"error": err, "id": id})
+		return fmt.Errorf("error while marking outbox message failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetOutboxStats counts unsent messages, messages created before stuckBefore are stuck.
+func (s *PostgresStorage) GetOutboxStats(ctx context.Context, stuckBefore time.Time) (models.OutboxStats, error) {
+	var stats models.OutboxStats
+	var oldest *time.Time
+	sql := fmt.Sprintf(
+		"SELECT count(*), count(*) FILTER (WHERE created_at < $1), min(created_at) FROM %s WHERE sent_at IS NULL",
+		OutboxTable)
+	if err := s.db.QueryRow(ctx, sql, stuckBefore).Scan(&stats.Pending, &stats.Stuck, &oldest); err != nil {
+		s.logger.Error("error while getting outbox stats", map[string]interface{}{"error": err})
+		return models.OutboxStats{}, fmt.Errorf("error while getting outbox stats: %w", err)
+	}
+
+	if oldest != nil {
+		stats.Oldest = *oldest
+	}
+
+	return stats, nil
+}
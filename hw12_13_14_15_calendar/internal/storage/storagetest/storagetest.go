@@ -0,0 +1,1102 @@
+// Package storagetest contains behaviour shared by every implementation of app.Storage.
+package storagetest
+
+//nolint:depguard
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/Baraulia/otus_hw/hw12_13_14_15_calendar/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Run checks the storage against the conformance suite, newStorage must return an empty storage on every call.
+func Run(t *testing.T, newStorage func(t *testing.T) app.Storage) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage app.Storage)
+	}{
+		{name: "create and get", test: testCreateAndGet},
+		{name: "update", test: testUpdate},
+		{name: "delete", test: testDelete},
+		{name: "user scoping", test: testUserScoping},
+		{name: "date busy", test: testDateBusy},
+		{name: "range boundaries", test: testRangeBoundaries},
+		{name: "recurring events", test: testRecurringEvents},
+		{name: "day windows follow time zone", test: testDayWindows},
+		{name: "recurring series keeps wall clock of its time zone", test: testRecurringTimeZone},
+		{name: "pages are ordered by time and id", test: testPagination},
+		{name: "search", test: testSearch},
+		{name: "patch", test: testPatch},
+		{name: "optimistic concurrency", test: testVersions},
+		{name: "trash", test: testTrash},
+		{name: "audit log", test: testAudit},
+		{name: "batch", test: testBatch},
+		{name: "atomic batch", test: testAtomicBatch},
+		{name: "changes", test: testChanges},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newStorage(t))
+		})
+	}
+}
+
+// NotificationStorage is a storage the scheduler takes notifications from.
+type NotificationStorage interface {
+	app.Storage
+	GetNotifications(ctx context.Context, from, to time.Time) ([]models.Notification, error)
+	GetWatermark(ctx context.Context, name string) (time.Time, error)
+	SetWatermark(ctx context.Context, name string, watermark time.Time) error
+	GetNotificationState(ctx context.Context, notification models.Notification) (models.NotificationState, error)
+	MarkNotificationPublished(ctx context.Context, notification models.Notification) error
+	ClaimNotification(ctx context.Context, notification models.Notification) (bool, error)
+	ReleaseNotification(ctx context.Context, notification models.Notification) error
+	MarkNotificationDelivered(ctx context.Context, notification models.Notification) (bool, error)
+	EnqueueNotifications(ctx context.Context, watermarkName string, from, to time.Time) (int, error)
+	GetOutbox(ctx context.Context, now time.Time, limit int) ([]models.OutboxMessage, error)
+	MarkOutboxSent(ctx context.Context, message models.OutboxMessage) error
+	MarkOutboxFailed(ctx context.Context, id int64, nextAttempt time.Time, reason string) error
+	GetOutboxStats(ctx context.Context, stuckBefore time.Time) (models.OutboxStats, error)
+}
+
+// RunNotifications checks the notifications of the storage, newStorage must return an empty storage on every call.
+func RunNotifications(t *testing.T, newStorage func(t *testing.T) NotificationStorage) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, storage NotificationStorage)
+	}{
+		{name: "notifications of a window", test: testNotificationWindow},
+		{name: "watermark", test: testWatermark},
+		{name: "ledger", test: testLedger},
+		{name: "outbox", test: testOutbox},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newStorage(t))
+		})
+	}
+}
+
+func testCreateAndGet(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+	notification := start.Add(-15 * time.Minute)
+	event := models.Event{
+		// Storages generate identifiers themselves.
+		ID:               uuid.New().String(),
+		Header:           "header",
+		Description:      "description",
+		UserID:           "user",
+		EventTime:        start,
+		FinishEventTime:  &finish,
+		NotificationTime: &notification,
+		TimeZone:         "Europe/Berlin",
+		RemindBefore:     models.Duration(15 * time.Minute),
+	}
+
+	id, err := storage.CreateEvent(ctx, event)
+	require.NoError(t, err)
+	require.NoError(t, uuid.Validate(id))
+	require.NotEqual(t, event.ID, id)
+
+	stored, err := storage.GetEvent(ctx, id)
+	require.NoError(t, err)
+	event.ID = id
+	requireEventEqual(t, event, stored)
+
+	_, err = storage.GetEvent(ctx, uuid.New().String())
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+}
+
+func testUpdate(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	id, err := storage.CreateEvent(ctx, models.Event{Header: "header", UserID: "user", EventTime: start})
+	require.NoError(t, err)
+
+	finish := start.Add(2 * time.Hour)
+	updated := models.Event{
+		ID:              id,
+		Header:          "new header",
+		Description:     "new description",
+		UserID:          "user",
+		EventTime:       start.Add(time.Hour),
+		FinishEventTime: &finish,
+		Recurrence:      "FREQ=WEEKLY",
+		ExDates:         []time.Time{start.AddDate(0, 0, 7).Add(time.Hour)},
+	}
+	require.NoError(t, storage.UpdateEvent(ctx, updated))
+
+	stored, err := storage.GetEvent(ctx, id)
+	require.NoError(t, err)
+	requireEventEqual(t, updated, stored)
+
+	updated.ID = uuid.New().String()
+	require.ErrorIs(t, storage.UpdateEvent(ctx, updated), app.ErrEventNotFound)
+
+	updated.ID = ""
+	require.ErrorIs(t, storage.UpdateEvent(ctx, updated), app.ErrInvalidArgument)
+}
+
+func testDelete(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	id, err := storage.CreateEvent(ctx, models.Event{Header: "header", UserID: "user", EventTime: time.Now()})
+	require.NoError(t, err)
+
+	require.NoError(t, storage.DeleteEvent(ctx, id, 0))
+
+	_, err = storage.GetEvent(ctx, id)
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+	require.ErrorIs(t, storage.DeleteEvent(ctx, id, 0), app.ErrEventNotFound)
+}
+
+func testUserScoping(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ownerCtx := app.ContextWithUserID(context.Background(), "owner")
+	strangerCtx := app.ContextWithUserID(context.Background(), "stranger")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+
+	id, err := storage.CreateEvent(ownerCtx, models.Event{Header: "private", UserID: "owner", EventTime: start})
+	require.NoError(t, err)
+
+	_, err = storage.GetEvent(strangerCtx, id)
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+
+	err = storage.UpdateEvent(strangerCtx, models.Event{ID: id, Header: "stolen", UserID: "stranger", EventTime: start})
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+	require.ErrorIs(t, storage.DeleteEvent(strangerCtx, id, 0), app.ErrEventForbidden)
+
+	events, err := storage.GetListEventsDuringDay(strangerCtx, start)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	events, err = storage.GetListEventsDuringDay(ownerCtx, start)
+	require.NoError(t, err)
+	require.Equal(t, []string{"private"}, headers(events))
+}
+
+func testDateBusy(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+	later := finish.Add(time.Hour)
+
+	_, err := storage.CreateEvent(ctx, models.Event{
+		Header: "meeting", UserID: "user", EventTime: start, FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+
+	_, err = storage.CreateEvent(ctx, models.Event{
+		Header: "overlap", UserID: "user", EventTime: start.Add(30 * time.Minute), FinishEventTime: &later,
+	})
+	require.ErrorIs(t, err, app.ErrDateBusy)
+
+	_, err = storage.CreateEvent(ctx, models.Event{
+		Header: "another user", UserID: "another user", EventTime: start, FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+
+	nextID, err := storage.CreateEvent(ctx, models.Event{
+		Header: "adjacent", UserID: "user", EventTime: finish, FinishEventTime: &later,
+	})
+	require.NoError(t, err)
+
+	err = storage.UpdateEvent(ctx, models.Event{
+		ID: nextID, Header: "moved", UserID: "user", EventTime: start, FinishEventTime: &later,
+	})
+	require.ErrorIs(t, err, app.ErrDateBusy)
+}
+
+func testRangeBoundaries(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	from := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	for _, event := range []models.Event{
+		{Header: "at start", UserID: "user", EventTime: from},
+		{Header: "inside", UserID: "user", EventTime: from.Add(12 * time.Hour)},
+		{Header: "at end", UserID: "user", EventTime: to},
+		{Header: "before", UserID: "user", EventTime: from.Add(-time.Second)},
+	} {
+		_, err := storage.CreateEvent(ctx, event)
+		require.NoError(t, err)
+	}
+
+	events, err := storage.GetListEventsInRange(ctx, from, to)
+	require.NoError(t, err)
+	require.Equal(t, []string{"at start", "inside"}, headers(events))
+
+	events, err = storage.GetListEventsDuringFewDays(ctx, from, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"at end", "at start", "inside"}, headers(events))
+}
+
+func testRecurringEvents(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+
+	id, err := storage.CreateEvent(ctx, models.Event{
+		Header:          "standup",
+		UserID:          "user",
+		EventTime:       start,
+		FinishEventTime: &finish,
+		Recurrence:      "FREQ=DAILY;COUNT=5",
+		ExDates:         []time.Time{start.AddDate(0, 0, 2)},
+	})
+	require.NoError(t, err)
+
+	events, err := storage.GetListEventsDuringDay(ctx, start.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, id, events[0].ID)
+	require.True(t, start.AddDate(0, 0, 1).Equal(events[0].EventTime))
+	require.True(t, finish.AddDate(0, 0, 1).Equal(*events[0].FinishEventTime))
+
+	events, err = storage.GetListEventsDuringDay(ctx, start.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	events, err = storage.GetListEventsDuringFewDays(ctx, start, 7)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+}
+
+func testDayWindows(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	tokyo := location(t, "Asia/Tokyo")
+	newYork := location(t, "America/New_York")
+
+	for _, event := range []models.Event{
+		{Header: "A", UserID: "user", EventTime: time.Date(2024, time.March, 10, 23, 30, 0, 0, time.UTC)},
+		{Header: "B", UserID: "user", EventTime: time.Date(2024, time.March, 10, 14, 0, 0, 0, time.UTC)},
+		{Header: "C", UserID: "user", EventTime: time.Date(2024, time.March, 11, 4, 30, 0, 0, time.UTC)},
+	} {
+		_, err := storage.CreateEvent(ctx, event)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		day      time.Time
+		expected []string
+	}{
+		{name: "UTC", day: time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC), expected: []string{"A", "B"}},
+		{name: "Tokyo", day: time.Date(2024, time.March, 10, 12, 0, 0, 0, tokyo), expected: []string{"B"}},
+		{name: "Tokyo next day", day: time.Date(2024, time.March, 11, 0, 0, 0, 0, tokyo), expected: []string{"A", "C"}},
+		// The switch to summer time makes the day 23 hours long in New York.
+		{name: "New York", day: time.Date(2024, time.March, 10, 0, 0, 0, 0, newYork), expected: []string{"A", "B"}},
+		{name: "New York next day", day: time.Date(2024, time.March, 11, 0, 0, 0, 0, newYork), expected: []string{"C"}},
+	}
+
+	for _, test := range tests {
+		events, err := storage.GetListEventsDuringDay(ctx, test.day)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, headers(events), test.name)
+	}
+
+	events, err := storage.GetListEventsDuringFewDays(ctx, time.Date(2024, time.March, 10, 0, 0, 0, 0, tokyo), 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "B", "C"}, headers(events))
+
+	from, to := app.WeekBounds(time.Date(2024, time.March, 10, 0, 0, 0, 0, newYork), time.Monday)
+	events, err = storage.GetListEventsInRange(ctx, from, to)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "B"}, headers(events))
+}
+
+func testRecurringTimeZone(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	berlin := location(t, "Europe/Berlin")
+
+	// The start is passed in UTC as it comes from the transport, only TimeZone keeps the wall clock.
+	_, err := storage.CreateEvent(ctx, models.Event{
+		Header:     "standup",
+		UserID:     "user",
+		EventTime:  time.Date(2024, time.March, 28, 9, 0, 0, 0, berlin).UTC(),
+		Recurrence: "FREQ=DAILY;COUNT=5",
+		TimeZone:   "Europe/Berlin",
+	})
+	require.NoError(t, err)
+
+	from, to := app.WeekBounds(time.Date(2024, time.March, 28, 0, 0, 0, 0, berlin), time.Monday)
+	events, err := storage.GetListEventsInRange(ctx, from, to)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	for _, event := range events {
+		require.Equal(t, 9, event.EventTime.In(berlin).Hour(), event.EventTime)
+		require.Equal(t, "Europe/Berlin", event.TimeZone)
+	}
+}
+
+func testPagination(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	from := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 3)
+
+	for _, event := range []models.Event{
+		{Header: "noon", UserID: "user", EventTime: from.Add(12 * time.Hour)},
+		{Header: "noon of another user", UserID: "another user", EventTime: from.Add(12 * time.Hour)},
+		{Header: "morning", UserID: "user", EventTime: from.Add(8 * time.Hour)},
+		{Header: "last", UserID: "user", EventTime: to.Add(-time.Minute)},
+		{Header: "standup", UserID: "third user", EventTime: from.Add(9 * time.Hour), Recurrence: "FREQ=DAILY"},
+	} {
+		_, err := storage.CreateEvent(ctx, event)
+		require.NoError(t, err)
+	}
+
+	all, err := storage.GetListEventsInRange(ctx, from, to)
+	require.NoError(t, err)
+	require.Len(t, all, 7)
+	for i := 1; i < len(all); i++ {
+		require.True(t, models.CursorOf(all[i-1]).Precedes(all[i]), "events %d and %d are out of order", i-1, i)
+	}
+
+	var paged []models.Event
+	var after *models.Cursor
+	for {
+		page, err := storage.GetListEventsPage(ctx, from, to, after, 2)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), 2)
+		if len(page) == 0 {
+			break
+		}
+
+		paged = append(paged, page...)
+		cursor := models.CursorOf(page[len(page)-1])
+		after = &cursor
+	}
+
+	require.Len(t, paged, len(all))
+	for i := range all {
+		require.Equal(t, all[i].ID, paged[i].ID)
+		require.True(t, all[i].EventTime.Equal(paged[i].EventTime))
+	}
+}
+
+func testSearch(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+
+	ids := make(map[string]string)
+	for _, event := range []models.Event{
+		{Header: "Sprint planning", Description: "Team A, room 5", UserID: "user", EventTime: start},
+		{Header: "Retro", Description: "after the sprint", UserID: "user", EventTime: start.AddDate(0, 0, 7)},
+		{Header: "Планирование спринта", UserID: "user", EventTime: start.AddDate(0, 0, 1)},
+		{Header: "sprint review", UserID: "another user", EventTime: start.AddDate(0, 0, 2)},
+		{Header: "Lunch", UserID: "user", EventTime: start.AddDate(0, 0, 3)},
+	} {
+		id, err := storage.CreateEvent(ctx, event)
+		require.NoError(t, err)
+		ids[event.Header] = id
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		query    models.SearchQuery
+		expected []string
+	}{
+		{
+			name:     "word of header or description",
+			ctx:      ctx,
+			query:    models.SearchQuery{Text: "SPRINT"},
+			expected: []string{"Sprint planning", "sprint review", "Retro"},
+		},
+		{
+			name:     "every word must match",
+			ctx:      ctx,
+			query:    models.SearchQuery{Text: "sprint team"},
+			expected: []string{"Sprint planning"},
+		},
+		{name: "cyrillic", ctx: ctx, query: models.SearchQuery{Text: "спринта"}, expected: []string{"Планирование спринта"}},
+		{name: "no match", ctx: ctx, query: models.SearchQuery{Text: "standup"}, expected: []string{}},
+		{
+			name:     "user filter",
+			ctx:      ctx,
+			query:    models.SearchQuery{Text: "sprint", UserID: "another user"},
+			expected: []string{"sprint review"},
+		},
+		{
+			name:     "caller scope",
+			ctx:      app.ContextWithUserID(ctx, "user"),
+			query:    models.SearchQuery{Text: "sprint"},
+			expected: []string{"Sprint planning", "Retro"},
+		},
+		{
+			name:     "range",
+			ctx:      ctx,
+			query:    models.SearchQuery{Text: "sprint", From: start.AddDate(0, 0, 1), To: start.AddDate(0, 0, 7)},
+			expected: []string{"sprint review"},
+		},
+		{
+			name:     "limit",
+			ctx:      ctx,
+			query:    models.SearchQuery{Text: "sprint", Limit: 1},
+			expected: []string{"Sprint planning"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, err := storage.SearchEvents(test.ctx, test.query)
+			require.NoError(t, err)
+
+			found := make([]string, 0, len(events))
+			for _, event := range events {
+				require.Equal(t, ids[event.Header], event.ID)
+				found = append(found, event.Header)
+			}
+			require.Equal(t, test.expected, found)
+		})
+	}
+
+	err := storage.UpdateEvent(ctx, models.Event{
+		ID: ids["Retro"], Header: "Retro", Description: "moved", UserID: "user", EventTime: start,
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.DeleteEvent(ctx, ids["sprint review"], 0))
+
+	events, err := storage.SearchEvents(ctx, models.SearchQuery{Text: "sprint"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Sprint planning"}, headers(events))
+
+	events, err = storage.SearchEvents(ctx, models.SearchQuery{Text: "moved"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Retro"}, headers(events))
+}
+
+func testPatch(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+	notification := start.Add(-15 * time.Minute)
+	event := models.Event{
+		Header:           "header",
+		Description:      "description",
+		UserID:           "user",
+		EventTime:        start,
+		FinishEventTime:  &finish,
+		NotificationTime: &notification,
+	}
+	id, err := storage.CreateEvent(ctx, event)
+	require.NoError(t, err)
+	event.ID = id
+
+	moved, movedFinish := start.Add(2*time.Hour), finish.Add(2*time.Hour)
+	patched, err := storage.PatchEvent(ctx, id, models.EventPatch{
+		Event:  models.Event{EventTime: moved, FinishEventTime: &movedFinish, Header: "ignored"},
+		Fields: []string{"eventTime", "finishEventTime"},
+	})
+	require.NoError(t, err)
+	event.EventTime, event.FinishEventTime = moved, &movedFinish
+	requireEventEqual(t, event, patched)
+
+	stored, err := storage.GetEvent(ctx, id)
+	require.NoError(t, err)
+	requireEventEqual(t, event, stored)
+
+	patched, err = storage.PatchEvent(ctx, id, models.EventPatch{Fields: []string{"notificationTime", "description"}})
+	require.NoError(t, err)
+	event.NotificationTime, event.Description = nil, ""
+	requireEventEqual(t, event, patched)
+
+	_, err = storage.CreateEvent(ctx, models.Event{
+		Header: "busy", UserID: "user", EventTime: start, FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+	_, err = storage.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{EventTime: start}, Fields: []string{"eventTime"},
+	})
+	require.ErrorIs(t, err, app.ErrDateBusy)
+
+	_, err = storage.PatchEvent(app.ContextWithUserID(ctx, "another user"), id, models.EventPatch{
+		Event: models.Event{Header: "stolen"}, Fields: []string{"header"},
+	})
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+
+	_, err = storage.PatchEvent(ctx, uuid.New().String(), models.EventPatch{
+		Event: models.Event{Header: "missing"}, Fields: []string{"header"},
+	})
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+}
+
+func testVersions(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	id, err := storage.CreateEvent(ctx, models.Event{Header: "header", UserID: "user", EventTime: start})
+	require.NoError(t, err)
+
+	event, err := storage.GetEvent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), event.Version)
+
+	// The first writer wins, the second one still holds version 1.
+	event.Header = "first"
+	require.NoError(t, storage.UpdateEvent(ctx, event))
+	event.Header = "second"
+	require.ErrorIs(t, storage.UpdateEvent(ctx, event), app.ErrVersionMismatch)
+
+	stored, err := storage.GetEvent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "first", stored.Header)
+	require.Equal(t, int64(2), stored.Version)
+
+	patched, err := storage.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{Header: "patched", Version: 2}, Fields: []string{"header"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), patched.Version)
+
+	_, err = storage.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{Header: "stale", Version: 2}, Fields: []string{"header"},
+	})
+	require.ErrorIs(t, err, app.ErrVersionMismatch)
+
+	// Zero version overwrites unconditionally.
+	require.NoError(t, storage.UpdateEvent(ctx, models.Event{
+		ID: id, Header: "forced", UserID: "user", EventTime: start,
+	}))
+
+	require.ErrorIs(t, storage.DeleteEvent(ctx, id, 3), app.ErrVersionMismatch)
+	require.ErrorIs(t, storage.DeleteEvent(app.ContextWithUserID(ctx, "another user"), id, 4), app.ErrEventForbidden)
+	require.NoError(t, storage.DeleteEvent(ctx, id, 4))
+	require.ErrorIs(t, storage.DeleteEvent(ctx, id, 4), app.ErrEventNotFound)
+}
+
+func testTrash(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	ownerCtx := app.ContextWithUserID(ctx, "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+	id, err := storage.CreateEvent(ctx, models.Event{
+		Header: "meeting", UserID: "user", EventTime: start, FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+	_, err = storage.RestoreEvent(ownerCtx, id)
+	require.ErrorIs(t, err, app.ErrEventNotDeleted)
+
+	require.NoError(t, storage.DeleteEvent(ownerCtx, id, 0))
+
+	_, err = storage.GetEvent(ownerCtx, id)
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+	events, err := storage.GetListEventsDuringDay(ownerCtx, start)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	events, err = storage.SearchEvents(ownerCtx, models.SearchQuery{Text: "meeting"})
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	trash, err := storage.GetListDeletedEvents(ownerCtx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, id, trash[0].ID)
+	require.NotNil(t, trash[0].DeletedAt)
+
+	trash, err = storage.GetListDeletedEvents(app.ContextWithUserID(ctx, "another user"))
+	require.NoError(t, err)
+	require.Empty(t, trash)
+	_, err = storage.RestoreEvent(app.ContextWithUserID(ctx, "another user"), id)
+	require.ErrorIs(t, err, app.ErrEventForbidden)
+
+	// Deleted events free their time slot, so the restore fails while it is taken again.
+	busyID, err := storage.CreateEvent(ctx, models.Event{
+		Header: "another meeting", UserID: "user", EventTime: start, FinishEventTime: &finish,
+	})
+	require.NoError(t, err)
+	_, err = storage.RestoreEvent(ownerCtx, id)
+	require.ErrorIs(t, err, app.ErrDateBusy)
+	require.NoError(t, storage.DeleteEvent(ownerCtx, busyID, 0))
+
+	restored, err := storage.RestoreEvent(ownerCtx, id)
+	require.NoError(t, err)
+	require.Nil(t, restored.DeletedAt)
+	require.Equal(t, int64(3), restored.Version)
+
+	events, err = storage.GetListEventsDuringDay(ownerCtx, start)
+	require.NoError(t, err)
+	require.Equal(t, []string{"meeting"}, headers(events))
+
+	_, err = storage.RestoreEvent(ownerCtx, uuid.New().String())
+	require.ErrorIs(t, err, app.ErrEventNotFound)
+}
+
+func testAudit(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := context.Background()
+	ownerCtx := app.ContextWithUserID(ctx, "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+
+	id, err := storage.CreateEvent(ownerCtx, models.Event{Header: "meeting", UserID: "user", EventTime: start})
+	require.NoError(t, err)
+	require.NoError(t, storage.UpdateEvent(ownerCtx, models.Event{
+		ID: id, Header: "planning", UserID: "user", EventTime: start,
+	}))
+	// Changes without identity come from internal processes.
+	_, err = storage.PatchEvent(ctx, id, models.EventPatch{
+		Event: models.Event{Description: "sprint"}, Fields: []string{"description"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.DeleteEvent(ownerCtx, id, 3))
+	_, err = storage.RestoreEvent(ownerCtx, id)
+	require.NoError(t, err)
+
+	// Failed changes and rolled back batches are not recorded.
+	require.ErrorIs(t, storage.DeleteEvent(ownerCtx, id, 1), app.ErrVersionMismatch)
+	results, err := storage.ApplyBatch(ownerCtx, []models.BatchItem{
+		{Operation: models.BatchUpdate, Event: models.Event{ID: id, Header: "rolled back", UserID: "user", EventTime: start}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: id, Version: 1}},
+	}, true)
+	require.NoError(t, err)
+	require.ErrorIs(t, results[1].Err, app.ErrVersionMismatch)
+	results, err = storage.ApplyBatch(ownerCtx, []models.BatchItem{
+		{Operation: models.BatchUpdate, Event: models.Event{ID: id, Header: "review", UserID: "user", EventTime: start}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: id, Version: 1}},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	require.ErrorIs(t, results[1].Err, app.ErrVersionMismatch)
+
+	history, err := storage.GetEventHistory(ownerCtx, id)
+	require.NoError(t, err)
+	actions := make([]models.AuditAction, 0, len(history))
+	for i, entry := range history {
+		require.Equal(t, id, entry.EventID)
+		require.Equal(t, "user", entry.UserID)
+		require.False(t, entry.Time.IsZero())
+		if i > 0 {
+			require.Less(t, history[i-1].ID, entry.ID)
+		}
+		actions = append(actions, entry.Action)
+	}
+	require.Equal(t, []models.AuditAction{
+		models.AuditCreate, models.AuditUpdate, models.AuditUpdate, models.AuditDelete, models.AuditRestore,
+		models.AuditUpdate,
+	}, actions)
+
+	// Images of the event are the states the change was made to and the state it left.
+	require.Equal(t, "user", history[0].Actor)
+	require.Nil(t, history[0].Before)
+	require.Equal(t, "meeting", history[0].After.Header)
+	require.Equal(t, int64(1), history[0].After.Version)
+	require.Equal(t, "meeting", history[1].Before.Header)
+	require.Equal(t, "planning", history[1].After.Header)
+	require.Equal(t, int64(2), history[1].After.Version)
+	require.Equal(t, "", history[2].Actor)
+	require.Equal(t, "", history[2].Before.Description)
+	require.Equal(t, "sprint", history[2].After.Description)
+	require.Equal(t, int64(3), history[3].Before.Version)
+	require.Nil(t, history[3].After)
+	require.Nil(t, history[4].Before)
+	require.Equal(t, int64(5), history[4].After.Version)
+	require.Equal(t, "planning", history[5].Before.Header)
+	require.Equal(t, "review", history[5].After.Header)
+
+	history, err = storage.GetEventHistory(app.ContextWithUserID(ctx, "another user"), id)
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
+
+func testBatch(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	finish := start.Add(time.Hour)
+	existingID, err := storage.CreateEvent(ctx, models.Event{Header: "existing", UserID: "user", EventTime: start})
+	require.NoError(t, err)
+
+	results, err := storage.ApplyBatch(ctx, []models.BatchItem{
+		{Operation: models.BatchCreate, Event: models.Event{
+			Header: "first", UserID: "user", EventTime: start, FinishEventTime: &finish,
+		}},
+		{Operation: models.BatchCreate, Event: models.Event{
+			Header: "overlapping", UserID: "user", EventTime: start, FinishEventTime: &finish,
+		}},
+		{Operation: models.BatchUpdate, Event: models.Event{
+			ID: existingID, Header: "updated", UserID: "user", EventTime: start, Version: 1,
+		}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: uuid.New().String()}},
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	require.NoError(t, results[0].Err)
+	require.NotEmpty(t, results[0].ID)
+	require.Equal(t, int64(1), results[0].Event.Version)
+	require.ErrorIs(t, results[1].Err, app.ErrDateBusy)
+	require.NoError(t, results[2].Err)
+	require.Equal(t, existingID, results[2].ID)
+	require.Equal(t, int64(2), results[2].Event.Version)
+	require.ErrorIs(t, results[3].Err, app.ErrEventNotFound)
+
+	events, err := storage.GetListEventsDuringDay(ctx, start)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "updated"}, headers(events))
+
+	results, err = storage.ApplyBatch(ctx, []models.BatchItem{
+		{Operation: models.BatchDelete, Event: models.Event{ID: results[0].ID, Version: 1}},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	events, err = storage.GetListEventsDuringDay(ctx, start)
+	require.NoError(t, err)
+	require.Equal(t, []string{"updated"}, headers(events))
+}
+
+func testAtomicBatch(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx := app.ContextWithUserID(context.Background(), "user")
+	start := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	existingID, err := storage.CreateEvent(ctx, models.Event{Header: "existing", UserID: "user", EventTime: start})
+	require.NoError(t, err)
+	anotherID, err := storage.CreateEvent(ctx, models.Event{Header: "another", UserID: "user", EventTime: start})
+	require.NoError(t, err)
+
+	results, err := storage.ApplyBatch(ctx, []models.BatchItem{
+		{Operation: models.BatchCreate, Event: models.Event{Header: "created", UserID: "user", EventTime: start}},
+		{Operation: models.BatchUpdate, Event: models.Event{
+			ID: existingID, Header: "updated", UserID: "user", EventTime: start,
+		}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: anotherID}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: existingID, Version: 1}},
+	}, true)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	require.ErrorIs(t, results[3].Err, app.ErrVersionMismatch)
+
+	// Nothing of the failed batch is left, including the search index.
+	events, err := storage.GetListEventsDuringDay(ctx, start)
+	require.NoError(t, err)
+	require.Equal(t, []string{"another", "existing"}, headers(events))
+	event, err := storage.GetEvent(ctx, existingID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), event.Version)
+	found, err := storage.SearchEvents(ctx, models.SearchQuery{Text: "updated"})
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	results, err = storage.ApplyBatch(ctx, []models.BatchItem{
+		{Operation: models.BatchCreate, Event: models.Event{Header: "created", UserID: "user", EventTime: start}},
+		{Operation: models.BatchDelete, Event: models.Event{ID: anotherID, Version: 1}},
+	}, true)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+	events, err = storage.GetListEventsDuringDay(ctx, start)
+	require.NoError(t, err)
+	require.Equal(t, []string{"created", "existing"}, headers(events))
+}
+
+func testChanges(t *testing.T, storage app.Storage) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start, err := storage.GetRevision(ctx)
+	require.NoError(t, err)
+	revisions, err := storage.WatchRevisions(ctx)
+	require.NoError(t, err)
+
+	eventTime := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	for _, userID := range []string{"user", "another user", "user", "user"} {
+		_, err = storage.CreateEvent(app.ContextWithUserID(ctx, userID), models.Event{
+			Header: "meeting of " + userID, UserID: userID, EventTime: eventTime,
+		})
+		require.NoError(t, err)
+	}
+
+	select {
+	case revision := <-revisions:
+		require.Greater(t, revision, start)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "revision of the new audit entry was not reported")
+	}
+
+	latest, err := storage.GetRevision(ctx)
+	require.NoError(t, err)
+	require.Equal(t, start+4, latest)
+
+	userCtx := app.ContextWithUserID(ctx, "user")
+	changes, err := storage.GetChanges(userCtx, start, 2)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, start+1, changes[0].ID)
+	require.Equal(t, start+3, changes[1].ID)
+
+	changes, err = storage.GetChanges(userCtx, changes[1].ID, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, latest, changes[0].ID)
+	require.Equal(t, models.AuditCreate, changes[0].Action)
+	require.Equal(t, "meeting of user", changes[0].After.Header)
+
+	changes, err = storage.GetChanges(userCtx, latest, 10)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-revisions
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond, "revisions are not closed after ctx is done")
+}
+
+func testNotificationWindow(t *testing.T, storage NotificationStorage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	notification := start.Add(-5 * time.Minute)
+	standup := start.AddDate(0, 0, -3).Add(-8 * time.Minute)
+	standupNotification := standup.Add(-7 * time.Minute)
+	events := []models.Event{
+		models.Event{Header: "reminded", UserID: "user", EventTime: start,
+			RemindBefore: models.Duration(10 * time.Minute)}.WithReminder(),
+		{Header: "notified", UserID: "user", EventTime: start.Add(time.Hour), NotificationTime: &notification},
+		{Header: "silent", UserID: "user", EventTime: start.Add(2 * time.Hour)},
+		{Header: "deleted", UserID: "user", EventTime: start.Add(3 * time.Hour), NotificationTime: &notification},
+		{
+			Header: "standup", UserID: "user", EventTime: standup, NotificationTime: &standupNotification,
+			Recurrence: "FREQ=DAILY",
+		},
+	}
+	ids := make(map[string]string)
+	for _, event := range events {
+		id, err := storage.CreateEvent(ctx, event)
+		require.NoError(t, err)
+		ids[event.Header] = id
+	}
+	require.NoError(t, storage.DeleteEvent(ctx, ids["deleted"], 0))
+
+	notifications, err := storage.GetNotifications(ctx, start.Add(-15*time.Minute), start.Add(-5*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+	require.Equal(t, ids["standup"], notifications[0].ID)
+	require.True(t, standup.AddDate(0, 0, 3).Equal(notifications[0].EventTime))
+	require.True(t, start.Add(-15*time.Minute).Equal(notifications[0].NotificationTime))
+	require.Equal(t, ids["reminded"], notifications[1].ID)
+	require.Equal(t, "reminded", notifications[1].EventHeader)
+	require.Equal(t, "user", notifications[1].UserID)
+	require.True(t, start.Equal(notifications[1].EventTime))
+	require.True(t, start.Add(-10*time.Minute).Equal(notifications[1].NotificationTime))
+
+	// The end of a window is not included, the next window starts with it.
+	notifications, err = storage.GetNotifications(ctx, start.Add(-5*time.Minute), start)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, ids["notified"], notifications[0].ID)
+
+	notifications, err = storage.GetNotifications(ctx, start, start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, notifications)
+}
+
+func testWatermark(t *testing.T, storage NotificationStorage) {
+	t.Helper()
+	ctx := context.Background()
+	watermark, err := storage.GetWatermark(ctx, "scheduler")
+	require.NoError(t, err)
+	require.True(t, watermark.IsZero())
+
+	first := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.SetWatermark(ctx, "scheduler", first))
+	require.NoError(t, storage.SetWatermark(ctx, "scheduler", first.Add(time.Minute)))
+	require.NoError(t, storage.SetWatermark(ctx, "other", first))
+
+	watermark, err = storage.GetWatermark(ctx, "scheduler")
+	require.NoError(t, err)
+	require.True(t, first.Add(time.Minute).Equal(watermark), "watermark %v", watermark)
+}
+
+func testLedger(t *testing.T, storage NotificationStorage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	notification := models.Notification{ID: uuid.New().String(), EventTime: start}
+	next := models.Notification{ID: notification.ID, EventTime: start.AddDate(0, 0, 1)}
+
+	state, err := storage.GetNotificationState(ctx, notification)
+	require.NoError(t, err)
+	require.Empty(t, state)
+
+	require.NoError(t, storage.MarkNotificationPublished(ctx, notification))
+	state, err = storage.GetNotificationState(ctx, notification)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationPublished, state)
+
+	// A notification is claimed by one sender at a time, a released claim can be taken again.
+	claimed, err := storage.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = storage.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	require.False(t, claimed)
+	require.NoError(t, storage.ReleaseNotification(ctx, notification))
+	state, err = storage.GetNotificationState(ctx, notification)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationPublished, state)
+	claimed, err = storage.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	delivered, err := storage.MarkNotificationDelivered(ctx, notification)
+	require.NoError(t, err)
+	require.True(t, delivered)
+	claimed, err = storage.ClaimNotification(ctx, notification)
+	require.NoError(t, err)
+	require.False(t, claimed)
+	delivered, err = storage.MarkNotificationDelivered(ctx, notification)
+	require.NoError(t, err)
+	require.False(t, delivered)
+
+	// Publishing again does not take the delivered state back.
+	require.NoError(t, storage.MarkNotificationPublished(ctx, notification))
+	state, err = storage.GetNotificationState(ctx, notification)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationDelivered, state)
+
+	// Occurrences of a recurring event are notified separately, a delivery may come before its publishing is recorded.
+	claimed, err = storage.ClaimNotification(ctx, next)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, storage.MarkNotificationPublished(ctx, next))
+	state, err = storage.GetNotificationState(ctx, next)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationDelivering, state)
+	delivered, err = storage.MarkNotificationDelivered(ctx, next)
+	require.NoError(t, err)
+	require.True(t, delivered)
+}
+
+func testOutbox(t *testing.T, storage NotificationStorage) {
+	t.Helper()
+	ctx := context.Background()
+	start := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	for i, header := range []string{"first", "second", "third"} {
+		notification := start.Add(time.Duration(i) * time.Minute)
+		_, err := storage.CreateEvent(ctx, models.Event{
+			Header: header, UserID: "user", EventTime: start.Add(time.Duration(i) * time.Hour),
+			NotificationTime: &notification,
+		})
+		require.NoError(t, err)
+	}
+
+	enqueued, err := storage.EnqueueNotifications(ctx, "outbox", start, start.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 2, enqueued)
+	watermark, err := storage.GetWatermark(ctx, "outbox")
+	require.NoError(t, err)
+	require.True(t, start.Add(2*time.Minute).Equal(watermark), "watermark %v", watermark)
+
+	// Windows overlapping enqueued notifications enqueue new ones only.
+	enqueued, err = storage.EnqueueNotifications(ctx, "outbox", start, start.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, enqueued)
+
+	now := time.Now()
+	outbox, err := storage.GetOutbox(ctx, now, 2)
+	require.NoError(t, err)
+	require.Len(t, outbox, 2)
+	require.Equal(t, "first", outbox[0].Notification.EventHeader)
+	require.Equal(t, "second", outbox[1].Notification.EventHeader)
+	require.True(t, start.Equal(outbox[0].Notification.NotificationTime))
+
+	require.NoError(t, storage.MarkOutboxSent(ctx, outbox[0]))
+	require.NoError(t, storage.MarkOutboxFailed(ctx, outbox[1].ID, now.Add(time.Hour), "broker is down"))
+	state, err := storage.GetNotificationState(ctx, outbox[0].Notification)
+	require.NoError(t, err)
+	require.Equal(t, models.NotificationPublished, state)
+
+	outbox, err = storage.GetOutbox(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	require.Equal(t, "third", outbox[0].Notification.EventHeader)
+
+	outbox, err = storage.GetOutbox(ctx, now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, outbox, 2)
+	require.Equal(t, "second", outbox[0].Notification.EventHeader)
+	require.Equal(t, 1, outbox[0].Attempts)
+	require.Equal(t, "broker is down", outbox[0].LastError)
+
+	stats, err := storage.GetOutboxStats(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Pending)
+	require.Zero(t, stats.Stuck)
+	stats, err = storage.GetOutboxStats(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Stuck)
+	require.False(t, stats.Oldest.IsZero())
+}
+
+func requireEventEqual(t *testing.T, expected, actual models.Event) {
+	t.Helper()
+	require.Equal(t, expected.ID, actual.ID)
+	require.Equal(t, expected.Header, actual.Header)
+	require.Equal(t, expected.Description, actual.Description)
+	require.Equal(t, expected.UserID, actual.UserID)
+	require.True(t, expected.EventTime.Equal(actual.EventTime), "event time %v, got %v",
+		expected.EventTime, actual.EventTime)
+	requireTimeEqual(t, expected.FinishEventTime, actual.FinishEventTime)
+	requireTimeEqual(t, expected.NotificationTime, actual.NotificationTime)
+	require.Equal(t, expected.Recurrence, actual.Recurrence)
+	require.Equal(t, expected.TimeZone, actual.TimeZone)
+	require.Equal(t, expected.RemindBefore, actual.RemindBefore)
+	require.Len(t, actual.ExDates, len(expected.ExDates))
+	for i := range expected.ExDates {
+		require.True(t, expected.ExDates[i].Equal(actual.ExDates[i]))
+	}
+}
+
+func requireTimeEqual(t *testing.T, expected, actual *time.Time) {
+	t.Helper()
+	if expected == nil {
+		require.Nil(t, actual)
+		return
+	}
+
+	require.NotNil(t, actual)
+	require.True(t, expected.Equal(*actual), "expected %v, got %v", *expected, *actual)
+}
+
+func location(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+
+	return loc
+}
+
+func headers(events []models.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Header)
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event
+    ADD COLUMN IF NOT EXISTS recurrence_rule VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS exception_dates TIMESTAMPTZ[];
+
+CREATE INDEX IF NOT EXISTS event_recurring_idx ON event (event_time) WHERE recurrence_rule <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS event_recurring_idx;
+
+ALTER TABLE event
+    DROP COLUMN IF EXISTS exception_dates,
+    DROP COLUMN IF EXISTS recurrence_rule;
+-- +goose StatementEnd
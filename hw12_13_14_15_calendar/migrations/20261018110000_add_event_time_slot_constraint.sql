@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Events finishing before they start or overlapping each other can not be repaired automatically,
+-- the migration stops listing them until they are fixed by hand.
+DO $$
+DECLARE
+    inverted TEXT;
+    overlapping TEXT;
+BEGIN
+    SELECT string_agg(id::TEXT, ', ' ORDER BY id) INTO inverted
+    FROM event
+    WHERE finish_event_time < event_time;
+    IF inverted IS NOT NULL THEN
+        RAISE EXCEPTION 'events finish before they start: %', inverted
+            USING HINT = 'Fix finish_event_time of these events before adding event_time_order_check.';
+    END IF;
+
+    SELECT string_agg(a.id || ' and ' || b.id, ', ' ORDER BY a.id, b.id) INTO overlapping
+    FROM event a
+    JOIN event b ON a.user_id = b.user_id AND a.id < b.id
+    WHERE a.finish_event_time IS NOT NULL AND a.recurrence_rule = ''
+        AND b.finish_event_time IS NOT NULL AND b.recurrence_rule = ''
+        AND tstzrange(a.event_time, a.finish_event_time) && tstzrange(b.event_time, b.finish_event_time);
+    IF overlapping IS NOT NULL THEN
+        RAISE EXCEPTION 'events of the same user overlap: %', overlapping
+            USING HINT = 'Move or delete overlapping events before adding event_time_slot_excl.';
+    END IF;
+END $$;
+
+ALTER TABLE event
+    ADD CONSTRAINT event_time_order_check CHECK (finish_event_time IS NULL OR finish_event_time >= event_time);
+
+ALTER TABLE event
+    ADD CONSTRAINT event_time_slot_excl EXCLUDE USING gist (
+        user_id WITH =,
+        tstzrange(event_time, finish_event_time) WITH &&
+    ) WHERE (finish_event_time IS NOT NULL AND recurrence_rule = '');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE event DROP CONSTRAINT IF EXISTS event_time_slot_excl;
+
+ALTER TABLE event DROP CONSTRAINT IF EXISTS event_time_order_check;
+-- +goose StatementEnd
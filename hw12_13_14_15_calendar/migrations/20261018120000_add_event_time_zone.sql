@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event
+    ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS event_user_time_idx ON event (user_id, event_time);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS event_user_time_idx;
+
+ALTER TABLE event
+    DROP COLUMN IF EXISTS time_zone;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('simple', header || ' ' || description)) STORED;
+
+CREATE INDEX IF NOT EXISTS event_search_idx ON event USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS event_search_idx;
+
+ALTER TABLE event
+    DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS event_deleted_at_idx ON event (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Events in the trash do not occupy time slots.
+ALTER TABLE event DROP CONSTRAINT IF EXISTS event_time_slot_excl;
+ALTER TABLE event
+    ADD CONSTRAINT event_time_slot_excl EXCLUDE USING gist (
+        user_id WITH =,
+        tstzrange(event_time, finish_event_time) WITH &&
+    ) WHERE (finish_event_time IS NOT NULL AND recurrence_rule = '' AND deleted_at IS NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM event WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE event DROP CONSTRAINT IF EXISTS event_time_slot_excl;
+ALTER TABLE event
+    ADD CONSTRAINT event_time_slot_excl EXCLUDE USING gist (
+        user_id WITH =,
+        tstzrange(event_time, finish_event_time) WITH &&
+    ) WHERE (finish_event_time IS NOT NULL AND recurrence_rule = '');
+
+DROP INDEX IF EXISTS event_deleted_at_idx;
+
+ALTER TABLE event
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The audit log has no foreign key on event, so the history survives purging of the trash.
+CREATE TABLE IF NOT EXISTS event_audit (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(16) NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS event_audit_event_id_idx ON event_audit (event_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_audit;
+-- +goose StatementEnd
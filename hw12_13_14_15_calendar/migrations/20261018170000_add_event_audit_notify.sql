@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Watchers of events are notified with the id of every new audit entry.
+CREATE OR REPLACE FUNCTION notify_event_audit() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('event_audit', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER event_audit_notify
+    AFTER INSERT ON event_audit
+    FOR EACH ROW EXECUTE FUNCTION notify_event_audit();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS event_audit_notify ON event_audit;
+DROP FUNCTION IF EXISTS notify_event_audit();
+-- +goose StatementEnd
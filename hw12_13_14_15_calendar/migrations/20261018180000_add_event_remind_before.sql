@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- notification_time precedes event_time by remind_before when it is not zero.
+ALTER TABLE event
+    ADD COLUMN IF NOT EXISTS remind_before INTERVAL NOT NULL DEFAULT '0';
+
+CREATE INDEX IF NOT EXISTS event_notification_time_idx ON event (notification_time)
+    WHERE notification_time IS NOT NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS event_notification_time_idx;
+
+ALTER TABLE event
+    DROP COLUMN IF EXISTS remind_before;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A watermark is the end of the last window of notifications a scheduler has published.
+CREATE TABLE IF NOT EXISTS scheduler_watermark (
+    name VARCHAR(64) PRIMARY KEY,
+    watermark TIMESTAMPTZ NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scheduler_watermark;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The ledger records notifications of occurrences of events that were published and delivered.
+CREATE TABLE IF NOT EXISTS notification_ledger (
+    event_id UUID NOT NULL,
+    occurrence TIMESTAMPTZ NOT NULL,
+    state VARCHAR(16) NOT NULL,
+    published_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    PRIMARY KEY (event_id, occurrence)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification_ledger;
+-- +goose StatementEnd
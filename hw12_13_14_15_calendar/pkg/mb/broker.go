@@ -28,12 +28,14 @@ type Broker struct {
 	reliable      bool
 	confirms      chan amqp.Confirmation
 }
+
+// Messages carry an idempotency key, consumers use it to recognize redelivered messages.
 type ConsumerMB interface {
-	ListenQueue(ctx context.Context, queueName string, routingKey string, handler func([]byte) bool)
+	ListenQueue(ctx context.Context, queueName string, routingKey string, handler func(key string, msg []byte) bool)
 }
 
 type ProducerMB interface {
-	Publish(routingKey string, msg []byte) error
+	Publish(routingKey, key string, msg []byte) error
 }
 
 func NewBroker(connectionURL, exchangeName, exchangeType string, logger Logger, reliable bool) *Broker {
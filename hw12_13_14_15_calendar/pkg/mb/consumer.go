@@ -41,7 +41,9 @@ func (c *Consumer) GetDeliveryChannel(queueName, routingKey string) (<-chan amqp
 	return deliveryChannel, nil
 }
 
-func (c *Consumer) ListenQueue(ctx context.Context, queueName string, routingKey string, handler func([]byte) bool) {
+func (c *Consumer) ListenQueue(
+	ctx context.Context, queueName string, routingKey string, handler func(key string, msg []byte) bool,
+) {
 	deliveryChannel, err := c.GetDeliveryChannel(queueName, routingKey)
 	if err != nil {
 		c.broker.logger.Fatal("error while getting delivery channel", map[string]interface{}{"error": err})
@@ -51,8 +53,8 @@ func (c *Consumer) ListenQueue(ctx context.Context, queueName string, routingKey
 	c.Handle(ctx, deliveryChannel, handler, queueName, routingKey)
 }
 
-func (c *Consumer) Handle(ctx context.Context, deliveryChannel <-chan amqp.Delivery, handleFunc func([]byte) bool,
-	queueName string, routingKey string,
+func (c *Consumer) Handle(ctx context.Context, deliveryChannel <-chan amqp.Delivery,
+	handleFunc func(string, []byte) bool, queueName string, routingKey string,
 ) {
 	for {
 		select {
@@ -84,8 +86,8 @@ func (c *Consumer) Handle(ctx context.Context, deliveryChannel <-chan amqp.Deliv
 	}
 }
 
-func (c *Consumer) processMessage(_ context.Context, msg amqp.Delivery, handleFunc func([]byte) bool) {
-	if ok := handleFunc(msg.Body); ok {
+func (c *Consumer) processMessage(_ context.Context, msg amqp.Delivery, handleFunc func(string, []byte) bool) {
+	if ok := handleFunc(msg.MessageId, msg.Body); ok {
 		c.broker.logger.Info("acknowledge message...", nil)
 		err := msg.Ack(true)
 		if err != nil {
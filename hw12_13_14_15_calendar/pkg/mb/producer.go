@@ -2,11 +2,15 @@ package mb
 
 //nolint:depguard
 import (
+	"errors"
 	"fmt"
 
 	"github.com/streadway/amqp"
 )
 
+// ErrNotConfirmed is returned by a reliable producer when the broker rejects a message or drops the channel.
+var ErrNotConfirmed = errors.New("publishing is not confirmed")
+
 type Producer struct {
 	broker *Broker
 }
@@ -17,11 +21,7 @@ func NewProducer(broker *Broker) *Producer {
 	}
 }
 
-func (p *Producer) Publish(routingKey string, msg []byte) error {
-	if p.broker.reliable {
-		defer p.confirmOne()
-	}
-
+func (p *Producer) Publish(routingKey, key string, msg []byte) error {
 	if err := p.broker.channel.Publish(
 		p.broker.exchangeName, // publish to an exchange
 		routingKey,            // routing to 0 or more queues
@@ -31,23 +31,31 @@ func (p *Producer) Publish(routingKey string, msg []byte) error {
 			Headers:         amqp.Table{},
 			ContentType:     "text/plain",
 			ContentEncoding: "",
+			MessageId:       key,
 			Body:            msg,
-			DeliveryMode:    amqp.Transient, // 1=non-persistent, 2=persistent
-			Priority:        0,              // 0-9
+			DeliveryMode:    amqp.Persistent, // 1=non-persistent, 2=persistent
+			Priority:        0,               // 0-9
 		},
 	); err != nil {
 		p.broker.logger.Error("error while publishing message", map[string]interface{}{"error": err})
 		return err
 	}
 
+	if p.broker.reliable {
+		return p.confirmOne()
+	}
+
 	return nil
 }
 
-func (p *Producer) confirmOne() {
+func (p *Producer) confirmOne() error {
 	p.broker.logger.Info("waiting for confirmation of one publishing", nil)
-	if confirmed := <-p.broker.confirms; confirmed.Ack {
-		p.broker.logger.Info(fmt.Sprintf("confirmed delivery with delivery tag: %d", confirmed.DeliveryTag), nil)
-	} else {
-		p.broker.logger.Info(fmt.Sprintf("failed delivery of delivery tag: %d", confirmed.DeliveryTag), nil)
+	confirmed := <-p.broker.confirms
+	if !confirmed.Ack {
+		p.broker.logger.Error(fmt.Sprintf("failed delivery of delivery tag: %d", confirmed.DeliveryTag), nil)
+		return fmt.Errorf("%w: delivery tag %d", ErrNotConfirmed, confirmed.DeliveryTag)
 	}
+	p.broker.logger.Info(fmt.Sprintf("confirmed delivery with delivery tag: %d", confirmed.DeliveryTag), nil)
+
+	return nil
 }
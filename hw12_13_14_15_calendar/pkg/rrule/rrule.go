@@ -0,0 +1,483 @@
+package rrule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+const (
+	untilLayout    = "20060102T150405Z"
+	untilDateOnly  = "20060102"
+	maxPeriods     = 100000
+	daysInWeek     = 7
+	monthsInYear   = 12
+	maxMonthDay    = 31
+	maxWeekOrdinal = 53
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry: a weekday with an optional ordinal ("2MO", "-1FR").
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Rule is the supported subset of an RFC 5545 recurrence rule.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+}
+
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return rule, fmt.Errorf("empty recurrence rule")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return rule, fmt.Errorf("invalid recurrence rule part: %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				return rule, fmt.Errorf("unsupported frequency: %s", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err != nil || rule.Interval < 1 {
+				return rule, fmt.Errorf("invalid interval: %s", val)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err != nil || rule.Count < 1 {
+				return rule, fmt.Errorf("invalid count: %s", val)
+			}
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return rule, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+			if err != nil {
+				return rule, err
+			}
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(val)
+			if err != nil {
+				return rule, err
+			}
+		default:
+			return rule, fmt.Errorf("unsupported recurrence rule part: %s", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, fmt.Errorf("FREQ is required part of recurrence rule")
+	}
+
+	if rule.Count != 0 && rule.Until != nil {
+		return rule, fmt.Errorf("COUNT and UNTIL must not occur in the same recurrence rule")
+	}
+
+	for _, day := range rule.ByDay {
+		if day.Ordinal != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return rule, fmt.Errorf("BYDAY ordinals are allowed only for MONTHLY and YEARLY rules")
+		}
+	}
+
+	return rule, nil
+}
+
+func parseUntil(val string) (time.Time, error) {
+	if until, err := time.Parse(untilLayout, val); err == nil {
+		return until, nil
+	}
+
+	until, err := time.Parse(untilDateOnly, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid until: %s", val)
+	}
+
+	// A date-only UNTIL is inclusive for the whole day.
+	return until.Add(24*time.Hour - time.Second), nil
+}
+
+func parseByDay(val string) ([]WeekdayNum, error) {
+	days := make([]WeekdayNum, 0)
+	for _, item := range strings.Split(val, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY value: %q", item)
+		}
+
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY value: %q", item)
+		}
+
+		day := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			ordinal, err := strconv.Atoi(prefix)
+			if err != nil || ordinal == 0 || ordinal > maxWeekOrdinal || ordinal < -maxWeekOrdinal {
+				return nil, fmt.Errorf("invalid BYDAY value: %q", item)
+			}
+			day.Ordinal = ordinal
+		}
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+func parseByMonthDay(val string) ([]int, error) {
+	days := make([]int, 0)
+	for _, item := range strings.Split(val, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || day == 0 || day > maxMonthDay || day < -maxMonthDay {
+			return nil, fmt.Errorf("invalid BYMONTHDAY value: %q", item)
+		}
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			name := strings.ToUpper(day.Weekday.String()[:2])
+			if day.Ordinal != 0 {
+				name = strconv.Itoa(day.Ordinal) + name
+			}
+			days = append(days, name)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Between returns the occurrences of the rule started at dtstart that fall into [from, to).
+// Occurrences listed in exDates are skipped, but still count towards COUNT.
+// The time of day and the location of occurrences are taken from dtstart.
+func (r Rule) Between(dtstart, from, to time.Time, exDates []time.Time) []time.Time {
+	occurrences := make([]time.Time, 0)
+	if !from.Before(to) {
+		return occurrences
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	period := 0
+	if r.Count == 0 {
+		// Without COUNT nothing depends on earlier periods, so we can jump close to the window.
+		period = r.periodsBefore(dtstart, from) / interval * interval
+	}
+
+	count := 0
+	for ; period < maxPeriods*interval; period += interval {
+		candidates := r.candidates(dtstart, period)
+		if len(candidates) > 0 && !candidates[0].Before(to) {
+			break
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+
+			if r.Until != nil && candidate.After(*r.Until) {
+				return occurrences
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return occurrences
+			}
+
+			if !candidate.Before(from) && candidate.Before(to) && !isExcluded(candidate, exDates) {
+				occurrences = append(occurrences, candidate)
+			}
+		}
+
+		if r.periodStart(dtstart, period).After(to) {
+			break
+		}
+	}
+
+	return occurrences
+}
+
+func isExcluded(candidate time.Time, exDates []time.Time) bool {
+	for _, exDate := range exDates {
+		if exDate.Equal(candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// periodsBefore returns how many whole periods lie between dtstart and t, minus one for safety.
+func (r Rule) periodsBefore(dtstart, t time.Time) int {
+	if !t.After(dtstart) {
+		return 0
+	}
+
+	t = t.In(dtstart.Location())
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = daysBetween(dtstart, t)
+	case Weekly:
+		periods = daysBetween(dtstart, t) / daysInWeek
+	case Monthly:
+		periods = (t.Year()-dtstart.Year())*monthsInYear + int(t.Month()-dtstart.Month())
+	case Yearly:
+		periods = t.Year() - dtstart.Year()
+	}
+
+	if periods > 0 {
+		periods--
+	}
+
+	return periods
+}
+
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+func (r Rule) periodStart(dtstart time.Time, period int) time.Time {
+	y, m, d := dtstart.Date()
+	loc := dtstart.Location()
+	switch r.Freq {
+	case Daily:
+		return time.Date(y, m, d+period, 0, 0, 0, 0, loc)
+	case Weekly:
+		weekStart := d - (int(dtstart.Weekday())+6)%daysInWeek
+		return time.Date(y, m, weekStart+period*daysInWeek, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(y, m+time.Month(period), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y+period, time.January, 1, 0, 0, 0, 0, loc)
+	}
+}
+
+// candidates returns the sorted occurrences generated by the given period, ignoring COUNT and UNTIL.
+func (r Rule) candidates(dtstart time.Time, period int) []time.Time {
+	start := r.periodStart(dtstart, period)
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{start}
+	case Weekly:
+		days = r.weekDays(dtstart, start)
+	case Monthly:
+		days = r.monthDays(dtstart, start)
+	case Yearly:
+		days = r.yearDays(dtstart, start)
+	}
+
+	h, mi, s := dtstart.Clock()
+	result := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		if r.Freq == Daily && !r.matchesByDay(day) {
+			continue
+		}
+
+		if r.Freq != Monthly && r.Freq != Yearly && !r.matchesByMonthDay(day) {
+			continue
+		}
+
+		result = append(result,
+			time.Date(day.Year(), day.Month(), day.Day(), h, mi, s, dtstart.Nanosecond(), dtstart.Location()))
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+
+	return result
+}
+
+func (r Rule) weekDays(dtstart, weekStart time.Time) []time.Time {
+	days := make([]time.Time, 0, daysInWeek)
+	for i := 0; i < daysInWeek; i++ {
+		day := weekStart.AddDate(0, 0, i)
+		if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+			continue
+		}
+
+		if len(r.ByDay) > 0 && !r.matchesByDay(day) {
+			continue
+		}
+
+		days = append(days, day)
+	}
+
+	return days
+}
+
+func (r Rule) monthDays(dtstart, monthStart time.Time) []time.Time {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		day := time.Date(monthStart.Year(), monthStart.Month(), dtstart.Day(), 0, 0, 0, 0, monthStart.Location())
+		if day.Month() != monthStart.Month() {
+			return nil
+		}
+
+		return []time.Time{day}
+	}
+
+	return r.expandRange(monthStart, monthStart.AddDate(0, 1, 0))
+}
+
+func (r Rule) yearDays(dtstart, yearStart time.Time) []time.Time {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		day := time.Date(yearStart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, yearStart.Location())
+		if day.Month() != dtstart.Month() {
+			return nil
+		}
+
+		return []time.Time{day}
+	}
+
+	return r.expandRange(yearStart, yearStart.AddDate(1, 0, 0))
+}
+
+// expandRange applies BYDAY (with ordinals relative to the range) and BYMONTHDAY to every day of [start, end).
+func (r Rule) expandRange(start, end time.Time) []time.Time {
+	all := make([]time.Time, 0, maxMonthDay)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		all = append(all, day)
+	}
+
+	days := make([]time.Time, 0)
+	for index, day := range all {
+		if len(r.ByMonthDay) > 0 && !r.matchesByMonthDay(day) {
+			continue
+		}
+
+		if len(r.ByDay) > 0 && !r.matchesByDayInRange(all, index) {
+			continue
+		}
+
+		days = append(days, day)
+	}
+
+	return days
+}
+
+func (r Rule) matchesByDay(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, byDay := range r.ByDay {
+		if byDay.Weekday == day.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) matchesByDayInRange(all []time.Time, index int) bool {
+	day := all[index]
+	for _, byDay := range r.ByDay {
+		if byDay.Weekday != day.Weekday() {
+			continue
+		}
+
+		if byDay.Ordinal == 0 {
+			return true
+		}
+
+		// Position of the day among the same weekdays of the range, counted from the start and from the end.
+		fromStart := index/daysInWeek + 1
+		fromEnd := -((len(all)-1-index)/daysInWeek + 1)
+		if byDay.Ordinal == fromStart || byDay.Ordinal == fromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) matchesByMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && lastDay+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedError bool
+	}{
+		{name: "daily", input: "FREQ=DAILY", expected: "FREQ=DAILY"},
+		{
+			name:     "with prefix",
+			input:    "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		},
+		{name: "monthly ordinal", input: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", expected: "FREQ=MONTHLY;COUNT=3;BYDAY=-1FR"},
+		{name: "until", input: "FREQ=DAILY;UNTIL=20240110T000000Z", expected: "FREQ=DAILY;UNTIL=20240110T000000Z"},
+		{name: "month day", input: "FREQ=MONTHLY;BYMONTHDAY=1,-1", expected: "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{name: "empty", input: "", expectedError: true},
+		{name: "without freq", input: "INTERVAL=2", expectedError: true},
+		{name: "unsupported freq", input: "FREQ=HOURLY", expectedError: true},
+		{name: "invalid interval", input: "FREQ=DAILY;INTERVAL=0", expectedError: true},
+		{name: "count and until", input: "FREQ=DAILY;COUNT=2;UNTIL=20240110T000000Z", expectedError: true},
+		{name: "invalid weekday", input: "FREQ=WEEKLY;BYDAY=XX", expectedError: true},
+		{name: "ordinal in weekly", input: "FREQ=WEEKLY;BYDAY=1MO", expectedError: true},
+		{name: "invalid month day", input: "FREQ=MONTHLY;BYMONTHDAY=32", expectedError: true},
+		{name: "unsupported part", input: "FREQ=DAILY;BYHOUR=10", expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := Parse(test.input)
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, rule.String())
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	// Monday.
+	dtstart := time.Date(2024, time.January, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     string
+		from     time.Time
+		to       time.Time
+		exDates  []time.Time
+		expected []time.Time
+	}{
+		{
+			name: "daily with count",
+			rule: "FREQ=DAILY;COUNT=3",
+			from: dtstart,
+			to:   dtstart.AddDate(0, 1, 0),
+			expected: []time.Time{
+				dtstart, dtstart.AddDate(0, 0, 1), dtstart.AddDate(0, 0, 2),
+			},
+		},
+		{
+			name: "daily with interval and window",
+			rule: "FREQ=DAILY;INTERVAL=10",
+			from: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, time.March, 21, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 11, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "weekly by day until",
+			rule: "FREQ=WEEKLY;BYDAY=MO,FR;UNTIL=20240112T093000Z",
+			from: dtstart,
+			to:   dtstart.AddDate(0, 1, 0),
+			expected: []time.Time{
+				dtstart, dtstart.AddDate(0, 0, 4), dtstart.AddDate(0, 0, 7), dtstart.AddDate(0, 0, 11),
+			},
+		},
+		{
+			name:    "weekly with exception date",
+			rule:    "FREQ=WEEKLY;COUNT=3",
+			from:    dtstart,
+			to:      dtstart.AddDate(0, 1, 0),
+			exDates: []time.Time{dtstart.AddDate(0, 0, 7)},
+			expected: []time.Time{
+				dtstart, dtstart.AddDate(0, 0, 14),
+			},
+		},
+		{
+			name: "monthly last friday",
+			rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			from: dtstart,
+			to:   dtstart.AddDate(1, 0, 0),
+			expected: []time.Time{
+				time.Date(2024, time.January, 26, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.February, 23, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 29, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "monthly by month day skips short months",
+			rule: "FREQ=MONTHLY;BYMONTHDAY=31",
+			from: dtstart,
+			to:   time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, time.January, 31, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "yearly",
+			rule: "FREQ=YEARLY;INTERVAL=2",
+			from: dtstart,
+			to:   time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				dtstart, dtstart.AddDate(2, 0, 0), dtstart.AddDate(4, 0, 0),
+			},
+		},
+		{
+			name:     "window before start",
+			rule:     "FREQ=DAILY",
+			from:     dtstart.AddDate(0, 0, -10),
+			to:       dtstart,
+			expected: []time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := Parse(test.rule)
+			require.NoError(t, err)
+
+			require.Equal(t, test.expected, rule.Between(dtstart, test.from, test.to, test.exDates))
+		})
+	}
+}
+
+func TestBetweenKeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	rule, err := Parse("FREQ=WEEKLY")
+	require.NoError(t, err)
+
+	dtstart := time.Date(2024, time.March, 25, 9, 0, 0, 0, loc)
+	occurrences := rule.Between(dtstart, dtstart, dtstart.AddDate(0, 0, 14), nil)
+
+	require.Len(t, occurrences, 2)
+	for _, occurrence := range occurrences {
+		require.Equal(t, 9, occurrence.Hour())
+	}
+	require.Equal(t, 7*24*time.Hour-time.Hour, occurrences[1].Sub(occurrences[0]))
+}
@@ -2,6 +2,8 @@ package internalhttp
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"time"
 )
@@ -42,6 +44,30 @@ func (s *Server) Start() error {
 	return err
 }
 
+// Serve accepts connections on the listener until the server is stopped.
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	if err != nil {
+		s.logger.Error("error while serving http", map[string]interface{}{"error": err})
+	}
+
+	return err
+}
+
+func (s *Server) Addr() string {
+	return s.httpServer.Addr
+}
+
+// Stop waits for active requests until ctx is done, the connections left, like event streams, are closed then.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return s.httpServer.Close()
+	}
+
+	return err
 }
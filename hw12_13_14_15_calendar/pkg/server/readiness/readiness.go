@@ -0,0 +1,106 @@
+// Package readiness combines readiness of the components of a process.
+package readiness
+
+//nolint:depguard
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Readiness is ready when every registered component is ready and the process is not shutting down.
+type Readiness struct {
+	mu           sync.RWMutex
+	components   map[string]bool
+	shuttingDown bool
+	listeners    []func(ready bool)
+}
+
+// New registers the components, none of them is ready yet.
+func New(components ...string) *Readiness {
+	r := &Readiness{components: make(map[string]bool, len(components))}
+	for _, component := range components {
+		r.components[component] = false
+	}
+
+	return r
+}
+
+// OnChange calls listener with the combined state now and every time it changes.
+func (r *Readiness) OnChange(listener func(ready bool)) {
+	r.mu.Lock()
+	r.listeners = append(r.listeners, listener)
+	ready := r.ready()
+	r.mu.Unlock()
+
+	listener(ready)
+}
+
+func (r *Readiness) Set(component string, ready bool) {
+	r.update(func() {
+		r.components[component] = ready
+	})
+}
+
+// ShutDown makes the process not ready for good.
+func (r *Readiness) ShutDown() {
+	r.update(func() {
+		r.shuttingDown = true
+	})
+}
+
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ready()
+}
+
+// ServeHTTP reports the state of every component, the status is 503 until the process is ready.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mu.RLock()
+	ready := r.ready()
+	components := make(map[string]bool, len(r.components))
+	for name, componentReady := range r.components {
+		components[name] = componentReady
+	}
+	r.mu.RUnlock()
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ready": ready, "components": components})
+}
+
+func (r *Readiness) update(change func()) {
+	r.mu.Lock()
+	before := r.ready()
+	change()
+	after := r.ready()
+	listeners := r.listeners
+	r.mu.Unlock()
+
+	if before != after {
+		for _, listener := range listeners {
+			listener(after)
+		}
+	}
+}
+
+func (r *Readiness) ready() bool {
+	if r.shuttingDown {
+		return false
+	}
+
+	for _, ready := range r.components {
+		if !ready {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,50 @@
+package readiness
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	r := New("http", "grpc")
+	var changes []bool
+	r.OnChange(func(ready bool) {
+		changes = append(changes, ready)
+	})
+	require.False(t, r.Ready())
+
+	r.Set("http", true)
+	require.False(t, r.Ready())
+
+	r.Set("grpc", true)
+	require.True(t, r.Ready())
+
+	r.Set("grpc", true)
+	r.ShutDown()
+	require.False(t, r.Ready())
+
+	r.Set("http", false)
+	r.Set("http", true)
+	require.False(t, r.Ready())
+	require.Equal(t, []bool{false, true, false}, changes)
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := New("http", "grpc")
+	r.Set("http", true)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.JSONEq(t, `{"ready":false,"components":{"http":true,"grpc":false}}`, w.Body.String())
+
+	r.Set("grpc", true)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"ready":true,"components":{"http":true,"grpc":true}}`, w.Body.String())
+}
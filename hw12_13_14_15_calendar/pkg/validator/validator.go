@@ -0,0 +1,254 @@
+// Package validator checks structs against the rules of their validate tags,
+// like `validate:"required|max:255"` or `validate:"after:EventTime"`.
+//
+// It is derived from hw09_struct_validator, which cannot be imported: it is a separate module that is not published,
+// and a replace directive to ../hw09_struct_validator would break the image build, which gets only this directory.
+// Compared to it, the package adds the required, after and before rules, limits strings by the count of characters,
+// names fields as in JSON and tells errors of the value apart from errors of the rule. The len rule and validation
+// of nested structs are left out, the calendar models do not need them.
+package validator
+
+//nolint:depguard
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrRequired                      = errors.New("value is required")
+	ErrLessThanMin                   = errors.New("value is less than minimum")
+	ErrMoreThanMax                   = errors.New("value is more than maximum")
+	ErrNotInSlice                    = errors.New("value is not in slice")
+	ErrDoesNotMatchRegularExpression = errors.New("value does not match the given regular expression")
+	ErrNotAfter                      = errors.New("value is not after")
+	ErrNotBefore                     = errors.New("value is not before")
+)
+
+const tagName = "validate"
+
+// ValidationError is a failed rule of a field, the field is named as in JSON.
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Err)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validate returns ValidationErrors with every failed rule of the struct,
+// other errors mean that the rules themselves are wrong.
+func Validate(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported type for validation: %s", value.Kind())
+	}
+
+	var validationErrors ValidationErrors
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag := field.Tag.Get(tagName)
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		for _, rule := range strings.Split(tag, "|") {
+			err := validateField(value, value.Field(i), rule)
+			if err == nil {
+				continue
+			}
+
+			var ruleErr ruleError
+			if errors.As(err, &ruleErr) {
+				return fmt.Errorf("invalid rule %q of field %s: %w", rule, field.Name, ruleErr.err)
+			}
+
+			validationErrors = append(validationErrors, ValidationError{Field: jsonName(field), Err: err})
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	return nil
+}
+
+// ruleError is an error of the rule, not of the validated value.
+type ruleError struct {
+	err error
+}
+
+func (e ruleError) Error() string {
+	return e.err.Error()
+}
+
+func validateField(structValue, fieldValue reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, ":")
+	switch name {
+	case "required":
+		if fieldValue.IsZero() {
+			return ErrRequired
+		}
+		return nil
+	case "min", "max":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			return ruleError{fmt.Errorf("unsupported value of %s: %w", name, err)}
+		}
+		return validateLimit(fieldValue, name, limit)
+	case "in":
+		return validateIncluding(fieldValue, strings.Split(arg, ","))
+	case "regexp":
+		return validateRegexp(fieldValue, arg)
+	case "after", "before":
+		other := structValue.FieldByName(arg)
+		if !other.IsValid() {
+			return ruleError{fmt.Errorf("there is no field %s", arg)}
+		}
+		return validateOrder(fieldValue, other, name, jsonNameOf(structValue.Type(), arg))
+	default:
+		return ruleError{fmt.Errorf("unsupported tag: %s", name)}
+	}
+}
+
+// validateLimit limits numbers by value, strings by the count of characters and slices by the count of elements.
+func validateLimit(val reflect.Value, name string, limit int) error {
+	var size int64
+	//nolint:exhaustive
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = val.Int()
+	case reflect.String:
+		size = int64(utf8.RuneCountInString(val.String()))
+	case reflect.Slice:
+		size = int64(val.Len())
+	default:
+		return ruleError{fmt.Errorf("unsupported type of field for validation: %s", val.Kind())}
+	}
+
+	switch {
+	case name == "min" && size < int64(limit):
+		return fmt.Errorf("%w %d", ErrLessThanMin, limit)
+	case name == "max" && size > int64(limit):
+		return fmt.Errorf("%w %d", ErrMoreThanMax, limit)
+	default:
+		return nil
+	}
+}
+
+func validateIncluding(val reflect.Value, slice []string) error {
+	var valueString string
+	//nolint:exhaustive
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		valueString = strconv.FormatInt(val.Int(), 10)
+	case reflect.String:
+		valueString = val.String()
+	default:
+		return ruleError{fmt.Errorf("unsupported type of field for validation: %s", val.Kind())}
+	}
+
+	for _, s := range slice {
+		if s == valueString {
+			return nil
+		}
+	}
+
+	return ErrNotInSlice
+}
+
+func validateRegexp(val reflect.Value, reg string) error {
+	if val.Kind() != reflect.String {
+		return ruleError{fmt.Errorf("unsupported type of field for validation: %s", val.Kind())}
+	}
+
+	r, err := regexp.Compile(reg)
+	if err != nil {
+		return ruleError{fmt.Errorf("it is not a regular expression: %s", reg)}
+	}
+
+	if !r.MatchString(val.String()) {
+		return ErrDoesNotMatchRegularExpression
+	}
+
+	return nil
+}
+
+// validateOrder compares times of two fields, the rule holds while either of them is not set.
+func validateOrder(val, other reflect.Value, name, otherName string) error {
+	valTime, err := timeOf(val)
+	if err != nil {
+		return err
+	}
+
+	otherTime, err := timeOf(other)
+	if err != nil {
+		return err
+	}
+
+	if valTime.IsZero() || otherTime.IsZero() {
+		return nil
+	}
+
+	switch {
+	case name == "after" && !valTime.After(otherTime):
+		return fmt.Errorf("%w %s", ErrNotAfter, otherName)
+	case name == "before" && !valTime.Before(otherTime):
+		return fmt.Errorf("%w %s", ErrNotBefore, otherName)
+	default:
+		return nil
+	}
+}
+
+func timeOf(val reflect.Value) (time.Time, error) {
+	if val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return time.Time{}, nil
+		}
+		val = val.Elem()
+	}
+
+	t, ok := val.Interface().(time.Time)
+	if !ok {
+		return time.Time{}, ruleError{fmt.Errorf("unsupported type of field for validation: %s", val.Type())}
+	}
+
+	return t, nil
+}
+
+func jsonNameOf(structType reflect.Type, fieldName string) string {
+	field, _ := structType.FieldByName(fieldName)
+
+	return jsonName(field)
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
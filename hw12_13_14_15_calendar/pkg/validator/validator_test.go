@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	Meeting struct {
+		Title    string     `json:"title" validate:"required|max:5"`
+		Room     string     `json:"room,omitempty" validate:"in:red,blue"`
+		Code     string     `validate:"regexp:^\\d+$"`
+		Seats    int        `json:"seats" validate:"min:1|max:10"`
+		Guests   []string   `json:"guests" validate:"max:2"`
+		Start    time.Time  `json:"start"`
+		Finish   *time.Time `json:"finish" validate:"after:Start"`
+		Reminder *time.Time `json:"reminder" validate:"before:Start"`
+	}
+
+	UnknownRule struct {
+		Title string `validate:"unknown"`
+	}
+
+	UnknownField struct {
+		Finish time.Time `validate:"after:Start"`
+	}
+
+	NotTime struct {
+		Start  string
+		Finish time.Time `validate:"after:Start"`
+	}
+)
+
+func TestValidate(t *testing.T) {
+	start := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	later := start.Add(time.Hour)
+	earlier := start.Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		in          interface{}
+		expectedErr error
+	}{
+		{
+			name: "valid",
+			in: Meeting{
+				Title: "Тест", Room: "red", Code: "42", Seats: 3, Guests: []string{"a"},
+				Start: start, Finish: &later, Reminder: &earlier,
+			},
+		},
+		{
+			name: "valid pointer without times",
+			in:   &Meeting{Title: "test", Room: "blue", Code: "1", Seats: 10, Finish: &later},
+		},
+		{
+			name: "invalid fields",
+			in: Meeting{
+				Room: "green", Code: "a1", Seats: 11, Guests: []string{"a", "b", "c"},
+				Start: start, Finish: &start, Reminder: &later,
+			},
+			expectedErr: ValidationErrors{
+				{Field: "title", Err: ErrRequired},
+				{Field: "room", Err: ErrNotInSlice},
+				{Field: "Code", Err: ErrDoesNotMatchRegularExpression},
+				{Field: "seats", Err: ErrMoreThanMax},
+				{Field: "guests", Err: ErrMoreThanMax},
+				{Field: "finish", Err: ErrNotAfter},
+				{Field: "reminder", Err: ErrNotBefore},
+			},
+		},
+		{
+			name:        "too long title",
+			in:          Meeting{Title: "tests!", Room: "red", Code: "1", Seats: 0},
+			expectedErr: ValidationErrors{{Field: "title", Err: ErrMoreThanMax}, {Field: "seats", Err: ErrLessThanMin}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.in)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErrors ValidationErrors
+			require.ErrorAs(t, err, &validationErrors)
+			expected := tt.expectedErr.(ValidationErrors) //nolint:errorlint
+			require.Len(t, validationErrors, len(expected))
+			for i := range expected {
+				require.Equal(t, expected[i].Field, validationErrors[i].Field)
+				require.ErrorIs(t, validationErrors[i], expected[i].Err)
+			}
+		})
+	}
+}
+
+func TestValidateMessages(t *testing.T) {
+	start := time.Date(2024, time.February, 14, 10, 0, 0, 0, time.UTC)
+	err := Validate(Meeting{Title: "test", Room: "red", Code: "1", Seats: 1, Start: start, Finish: &start})
+	require.EqualError(t, err, "finish: value is not after start")
+
+	err = Validate(Meeting{Title: "test", Room: "red", Code: "1", Seats: 20})
+	require.EqualError(t, err, "seats: value is more than maximum 10")
+}
+
+func TestValidateInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+	}{
+		{name: "not a struct", in: "test"},
+		{name: "unknown rule", in: UnknownRule{Title: "test"}},
+		{name: "unknown field", in: UnknownField{Finish: time.Now()}},
+		{name: "field is not a time", in: NotTime{Start: "now", Finish: time.Now()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.in)
+			require.Error(t, err)
+
+			var validationErrors ValidationErrors
+			require.False(t, errors.As(err, &validationErrors))
+		})
+	}
+}